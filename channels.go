@@ -0,0 +1,93 @@
+package wavy
+
+// convertChannels converts interleaved PCM16 data with 'fromChans' channels to have 'toChans' channels.
+// Going to mono averages all channels, mono to more channels duplicates the mono channel, and going from more
+// channels to fewer (but not mono) keeps the first channels, which in WAV/OGG order are front left and front right.
+//
+// Any incomplete frame at the end is dropped. If the channel counts are equal pcm is returned as is
+func convertChannels(pcm []byte, fromChans, toChans int) []byte {
+
+	if fromChans == toChans || fromChans <= 0 || toChans <= 0 {
+		return pcm
+	}
+
+	const bytesPerChanSample = 2
+	inFrameSize := fromChans * bytesPerChanSample
+	outFrameSize := toChans * bytesPerChanSample
+
+	frameCount := len(pcm) / inFrameSize
+	out := make([]byte, frameCount*outFrameSize)
+	for f := 0; f < frameCount; f++ {
+
+		inFrame := f * inFrameSize
+		outFrame := f * outFrameSize
+
+		if toChans == 1 {
+
+			sum := 0
+			for c := 0; c < fromChans; c++ {
+				sum += int(getPCM16Sample(pcm, inFrame+c*bytesPerChanSample))
+			}
+
+			putPCM16Sample(out, outFrame, int16(sum/fromChans))
+			continue
+		}
+
+		for c := 0; c < toChans; c++ {
+
+			inChan := c
+			if fromChans == 1 {
+				inChan = 0
+			} else if inChan >= fromChans {
+				inChan = fromChans - 1
+			}
+
+			putPCM16Sample(out, outFrame+c*bytesPerChanSample, getPCM16Sample(pcm, inFrame+inChan*bytesPerChanSample))
+		}
+	}
+
+	return out
+}
+
+// ToMono returns a new in-memory sound with the channels of s mixed down to mono.
+// Since sounds must have the context's channel count to be played, in a stereo context the mono signal is
+// put in both channels, so the result sounds mono but has the same layout and size as s.
+//
+// Panics if the sound is not in-memory
+func ToMono(s *Sound) *Sound {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be converted to mono")
+	}
+
+	mono := convertChannels(s.Data.(*SoundBuffer).Data, int(ChanCount), 1)
+	sb := &SoundBuffer{Data: convertChannels(mono, 1, int(ChanCount))}
+
+	newSound := copyInMemSoundWithData(s, sb)
+	newSound.Info.Size = sb.Len()
+	return newSound
+}
+
+// ToStereo returns a new in-memory sound with its own copy of the data of s laid out as stereo.
+// Loaded sounds always have the context's channel count, so in a stereo context this is a copy that doesn't share data with s.
+//
+// Panics if the sound is not in-memory, or if the context is not stereo since stereo sounds can't be played in it
+func ToStereo(s *Sound) *Sound {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be converted to stereo")
+	}
+
+	if ChanCount != SoundChannelCount_2 {
+		panic("sounds can only be converted to stereo in a stereo context")
+	}
+
+	data := s.Data.(*SoundBuffer).Data
+	stereo := make([]byte, len(data))
+	copy(stereo, data)
+	sb := &SoundBuffer{Data: stereo}
+
+	newSound := copyInMemSoundWithData(s, sb)
+	newSound.Info.Size = sb.Len()
+	return newSound
+}
@@ -0,0 +1,22 @@
+package wavy
+
+import "time"
+
+// clock is the source of time used by the timing functions (e.g. Wait), and exists so tests can replace it
+type clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// clk is the clock used by the package. Only tests should change it
+var clk clock = realClock{}
@@ -0,0 +1,393 @@
+package wavy
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves forward when Sleep is called, and records the sleeps
+type fakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	elapsed time.Duration
+	sleeps  []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now.Add(c.elapsed)
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.elapsed += d
+	c.sleeps = append(c.sleeps, d)
+}
+
+func (c *fakeClock) Elapsed() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.elapsed
+}
+
+var _ io.Seeker = &fakePlayer{}
+
+// fakePlayer plays for playDuration of fake clock time every time Play is called
+type fakePlayer struct {
+	lock         sync.Mutex
+	clk          *fakeClock
+	playDuration time.Duration
+	playUntil    time.Duration
+	playing      bool
+	playCount    int
+	volume       float64
+	unplayed     int
+}
+
+func (p *fakePlayer) Pause() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.playing = false
+}
+
+func (p *fakePlayer) Play() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.playing = true
+	p.playCount++
+	p.playUntil = p.clk.Elapsed() + p.playDuration
+}
+
+func (p *fakePlayer) IsPlaying() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.playing && p.clk.Elapsed() < p.playUntil
+}
+
+func (p *fakePlayer) Volume() float64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.volume
+}
+
+func (p *fakePlayer) SetVolume(volume float64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.volume = volume
+}
+
+func (p *fakePlayer) PlayCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.playCount
+}
+
+func (p *fakePlayer) Reset()                  {}
+func (p *fakePlayer) UnplayedBufferSize() int { return p.unplayed }
+func (p *fakePlayer) Err() error              { return nil }
+func (p *fakePlayer) Close() error            { return nil }
+
+func (p *fakePlayer) Seek(offset int64, whence int) (int64, error) {
+	return 0, nil
+}
+
+// newFakeSound returns a sound of length d that plays using a fake player and clock.
+// The package clock and format globals are replaced, and are restored when the test ends
+func newFakeSound(t *testing.T, d time.Duration) (*Sound, *fakePlayer, *fakeClock) {
+
+	oldClk, oldBytesPerSample, oldBytesPerSecond := clk, BytesPerSample, BytesPerSecond
+	t.Cleanup(func() {
+		clk, BytesPerSample, BytesPerSecond = oldClk, oldBytesPerSample, oldBytesPerSecond
+	})
+
+	fc := &fakeClock{now: time.Unix(0, 0)}
+	clk = fc
+	BytesPerSample = 4
+	BytesPerSecond = BytesPerSample * 44100
+
+	fp := &fakePlayer{clk: fc, playDuration: d}
+	data := make([]byte, ByteCountFromPlayTime(d))
+
+	s := &Sound{
+		Player:       fp,
+		PlayerSeeker: fp,
+		Data:         &SoundBuffer{Data: data},
+		Info: SoundInfo{
+			Mode: SoundMode_Memory,
+			Size: int64(len(data)),
+		},
+	}
+
+	return s, fp, fc
+}
+
+func TestWaitSleepsInChunks(t *testing.T) {
+
+	// Player.Play is used instead of PlayAsync so no other goroutines sleep on the clock
+	s, _, fc := newFakeSound(t, time.Second)
+	s.Player.Play()
+	s.Wait()
+
+	// Wait sleeps the remaining time in 25 chunks
+	if len(fc.sleeps) != 25 {
+		t.Errorf("Expected Wait to sleep 25 times but it slept %d times\n", len(fc.sleeps))
+		return
+	}
+
+	for i, d := range fc.sleeps {
+		if d != 40*time.Millisecond {
+			t.Errorf("Expected sleep %d to be 40ms but got %s\n", i, d)
+			return
+		}
+	}
+
+	if fc.Elapsed() != time.Second {
+		t.Errorf("Expected Wait to take 1s but it took %s\n", fc.Elapsed())
+		return
+	}
+}
+
+func TestLoopPlayCount(t *testing.T) {
+
+	s, fp, _ := newFakeSound(t, 100*time.Millisecond)
+	s.LoopAsync(3)
+	s.WaitLoop()
+
+	if fp.PlayCount() != 3 {
+		t.Errorf("Expected LoopAsync(3) to play 3 times but it played %d times\n", fp.PlayCount())
+		return
+	}
+
+	if stats := s.Stats(); stats.PlayCount != 1 || stats.LoopCount != 2 {
+		t.Errorf("Expected LoopAsync(3) to count as 1 play and 2 loops but got %+v\n", stats)
+		return
+	}
+}
+
+func TestStats(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Second)
+	s.PlayAsync()
+	s.Wait()
+	s.Pause()
+
+	// Other goroutines waiting on the sound also move the fake clock, so the duration can be a bit longer
+	stats := s.Stats()
+	if stats.PlayCount != 1 || stats.LoopCount != 0 || stats.TotalPlayedDuration < time.Second || stats.TotalPlayedDuration > 1100*time.Millisecond {
+		t.Errorf("Expected 1 play of about 1s but got %+v\n", stats)
+		return
+	}
+
+	s.ResetStats()
+	if s.Stats() != (SoundStats{}) {
+		t.Errorf("Expected stats to be zero after reset but got %+v\n", s.Stats())
+		return
+	}
+}
+
+func TestPlayPauseHooks(t *testing.T) {
+
+	s, fp, _ := newFakeSound(t, time.Second)
+
+	var calls []string
+	s.SetOnPlay(func() {
+
+		// The hook runs before playing starts
+		if fp.IsPlaying() {
+			t.Errorf("Expected OnPlay to run before the sound plays\n")
+		}
+		calls = append(calls, "play")
+	})
+	s.SetOnPause(func() { calls = append(calls, "pause") })
+
+	s.PlayAsync()
+	s.Pause()
+	s.LoopAsync(3)
+	s.Stop()
+
+	expected := []string{"play", "pause", "play", "pause"}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected hook calls to be %v but got %v\n", expected, calls)
+		return
+	}
+
+	s.SetOnPlay(nil)
+	s.SetOnPause(nil)
+	s.PlayAsync()
+	s.Pause()
+	if len(calls) != len(expected) {
+		t.Errorf("Expected no hook calls after removing the hooks but got %v\n", calls[len(expected):])
+		return
+	}
+}
+
+func TestCloseStopsLoop(t *testing.T) {
+
+	s, fp, _ := newFakeSound(t, 100*time.Millisecond)
+	fp.playDuration = 1 << 62
+	s.LoopAsync(-1)
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected closing a looping sound to succeed but got '%s'\n", err)
+		return
+	}
+
+	if fp.IsPlaying() {
+		t.Errorf("Expected the player to be paused before closing\n")
+		return
+	}
+
+	// The loop must end on its own instead of replaying the closed sound
+	done := make(chan struct{})
+	go func() {
+		s.WaitLoop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected loop to end after closing\n")
+		return
+	}
+
+	if fp.PlayCount() != 1 {
+		t.Errorf("Expected the closed sound to be played once but it was played %d times\n", fp.PlayCount())
+		return
+	}
+}
+
+func TestSetRemainingLoops(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, 100*time.Millisecond)
+	s.LoopAsync(-1)
+	s.SetRemainingLoops(2)
+
+	// An infinite loop that became finite must end on its own
+	done := make(chan struct{})
+	go func() {
+		s.WaitLoop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		s.Pause()
+		t.Errorf("Expected loop to end after setting the remaining loops\n")
+		return
+	}
+
+	if s.Stats().LoopCount < 2 {
+		t.Errorf("Expected at least 2 loops but got %d\n", s.Stats().LoopCount)
+		return
+	}
+}
+
+func TestCurrentAndTotalLoops(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, 100*time.Millisecond)
+
+	var current, total []int
+	s.loopAsync(3, 0, func(iteration int) {
+		current = append(current, s.CurrentLoop())
+		total = append(total, s.TotalLoops())
+	})
+	s.WaitLoop()
+
+	if len(current) != 3 {
+		t.Errorf("Expected 3 passes but got %d\n", len(current))
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		if current[i] != i+1 || total[i] != 3 {
+			t.Errorf("Expected pass %d to be loop %d of 3 but got %d of %d\n", i, i+1, current[i], total[i])
+			return
+		}
+	}
+
+	if s.CurrentLoop() != 0 || s.TotalLoops() != 0 {
+		t.Errorf("Expected loop counters to be zero after looping ended but got %d of %d\n", s.CurrentLoop(), s.TotalLoops())
+		return
+	}
+}
+
+func TestSeekOvershootPolicy(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Second)
+	defer SetSeekOvershootPolicy(SeekOvershootPolicy_Clamp)
+
+	SetSeekOvershootPolicy(SeekOvershootPolicy_Error)
+	if err := s.SeekToTimeErr(time.Second); err != nil {
+		t.Errorf("Expected seeking exactly to the end to work but got '%s'\n", err)
+		return
+	}
+
+	if err := s.SeekToTimeErr(time.Second + time.Millisecond); err != ErrSeekPastEnd {
+		t.Errorf("Expected ErrSeekPastEnd when seeking past the end but got '%v'\n", err)
+		return
+	}
+
+	// The source decides where the end is, even if Info.Size says otherwise
+	s.Info.Size *= 2
+	if err := s.SeekToTimeErr(1500 * time.Millisecond); err != ErrSeekPastEnd {
+		t.Errorf("Expected ErrSeekPastEnd when seeking past the end of the source but got '%v'\n", err)
+		return
+	}
+
+	SetSeekOvershootPolicy(SeekOvershootPolicy_Clamp)
+	if err := s.SeekToTimeErr(time.Hour); err != nil {
+		t.Errorf("Expected seek past the end to be clamped but got '%s'\n", err)
+		return
+	}
+}
+
+func TestBufferedDuration(t *testing.T) {
+
+	s, fp, _ := newFakeSound(t, time.Second)
+	fp.unplayed = int(ByteCountFromPlayTime(100 * time.Millisecond))
+	if s.BufferedDuration() != 100*time.Millisecond {
+		t.Errorf("Expected buffered duration to be 100ms but got '%s'\n", s.BufferedDuration())
+		return
+	}
+
+	s.Data = nil
+	if s.BufferedDuration() != 0 {
+		t.Errorf("Expected buffered duration to be zero after close but got '%s'\n", s.BufferedDuration())
+		return
+	}
+}
+
+func TestWaitPlayersStarted(t *testing.T) {
+
+	playing, _, fc := newFakeSound(t, time.Second)
+	playing.Player.Play()
+	waitPlayersStarted([]*Sound{playing}, time.Second)
+	if len(fc.sleeps) != 0 {
+		t.Errorf("Expected no waiting when all players are playing, but slept %d times\n", len(fc.sleeps))
+		return
+	}
+
+	// A player that never starts is only waited on till the timeout
+	notPlaying, _, fc := newFakeSound(t, time.Second)
+	waitPlayersStarted([]*Sound{playing, notPlaying}, 10*time.Millisecond)
+	if fc.Elapsed() != 10*time.Millisecond {
+		t.Errorf("Expected waiting to stop at the 10ms timeout but waited '%s'\n", fc.Elapsed())
+		return
+	}
+
+	// Sounds at their end never start, so they aren't waited on
+	fc.sleeps = nil
+	notPlaying.Data.Seek(0, io.SeekEnd)
+	waitPlayersStarted([]*Sound{notPlaying}, time.Second)
+	if len(fc.sleeps) != 0 {
+		t.Errorf("Expected no waiting for sounds at their end, but slept %d times\n", len(fc.sleeps))
+		return
+	}
+}
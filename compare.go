@@ -0,0 +1,48 @@
+package wavy
+
+import "bytes"
+
+// SoundsEqual reports whether two in-memory sounds have exactly the same PCM data, which is useful in tests of effects and conversions.
+// Only the data is compared, not the play position or settings like volume.
+//
+// Panics if either sound is not in-memory
+func SoundsEqual(a, b *Sound) bool {
+	return bytes.Equal(inMemPCM(a), inMemPCM(b))
+}
+
+// SoundsApproxEqual is like SoundsEqual but allows every sample to differ by up to 'tolerance', which is useful for comparing the output
+// of lossy paths (e.g. dithering or float conversions). The sounds must still have the same length.
+//
+// Panics if either sound is not in-memory, or if tolerance<0
+func SoundsApproxEqual(a, b *Sound, tolerance int16) bool {
+
+	if tolerance < 0 {
+		panic("tolerance can not be less than zero")
+	}
+
+	pcmA := inMemPCM(a)
+	pcmB := inMemPCM(b)
+	if len(pcmA) != len(pcmB) {
+		return false
+	}
+
+	for i := 0; i+1 < len(pcmA); i += 2 {
+
+		// int32 so the difference can't overflow
+		diff := int32(getPCM16Sample(pcmA, i)) - int32(getPCM16Sample(pcmB, i))
+		if diff < -int32(tolerance) || diff > int32(tolerance) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func inMemPCM(s *Sound) []byte {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be compared")
+	}
+
+	return s.Data.(*SoundBuffer).Data
+}
@@ -0,0 +1,44 @@
+package wavy
+
+import (
+	"bytes"
+)
+
+// NewSoundMemCompressed creates a sound from the bytes of an encoded sound file (e.g. the contents of an .ogg file),
+// keeping the encoded bytes in memory and decoding them on the fly while playing.
+// This trades some CPU for much lower memory use than NewSoundMem, which is useful for many short clips since decoded PCM
+// is usually several times bigger than compressed audio. Seeking works for all formats since the bytes are in memory.
+//
+// The sound is a streaming sound (Info.Mode is SoundMode_Streaming), so functions that need in-memory sounds don't accept it.
+// b must not be changed while the sound is in use.
+//
+// Loading failures are returned as a *LoadError with an empty path
+func NewSoundMemCompressed(b []byte, soundType SoundType) (*Sound, error) {
+
+	if soundType == SoundType_Unknown {
+		return nil, newLoadError("", soundType, errUnknownSoundType)
+	}
+
+	if !IsSoundTypeSupported(soundType) {
+		return nil, newLoadError("", soundType, ErrUnsupportedSoundType)
+	}
+
+	s := &Sound{
+		Info: SoundInfo{
+			Type: soundType,
+			Mode: SoundMode_Streaming,
+		},
+	}
+
+	err := soundFromReadSeeker(bytes.NewReader(b), s)
+	if err != nil {
+		return nil, newLoadError("", soundType, err)
+	}
+
+	if err := checkSampleRate(s); err != nil {
+		s.Close()
+		return nil, newLoadError("", soundType, err)
+	}
+
+	return s, nil
+}
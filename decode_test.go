@@ -0,0 +1,49 @@
+package wavy
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+func loadOggBenchData(b *testing.B) []byte {
+
+	data, err := os.ReadFile("./test_audio_files/camera.ogg")
+	if err != nil {
+		b.Fatalf("Failed to read ogg file. Err: %s\n", err)
+	}
+
+	return data
+}
+
+// BenchmarkReadAllOggPCM16 and BenchmarkReadAllOggUnhinted compare allocations of the sized decoder against
+// the previous ReadAll based decoding, which grows the float buffer while decoding then converts it all at once
+func BenchmarkReadAllOggPCM16(b *testing.B) {
+
+	data := loadOggBenchData(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := readAllOggPCM16(bytes.NewReader(data)); err != nil {
+			b.Fatalf("Failed to decode ogg. Err: %s\n", err)
+		}
+	}
+}
+
+func BenchmarkReadAllOggUnhinted(b *testing.B) {
+
+	data := loadOggBenchData(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+
+		floats, _, err := oggvorbis.ReadAll(bytes.NewReader(data))
+		if err != nil {
+			b.Fatalf("Failed to decode ogg. Err: %s\n", err)
+		}
+
+		f32ToPCM16(floats, nil)
+	}
+}
@@ -0,0 +1,123 @@
+package wavy
+
+import (
+	"math"
+	"time"
+)
+
+// ApplyCompressor reduces the dynamic range of an in-memory sound by turning down parts louder than thresholdDb,
+// which is useful for leveling things like speech. Levels are in dBFS, so thresholdDb is <=0 where 0 is the loudest possible level.
+// For every 'ratio' dB the input goes over the threshold the output only goes over it by 1 dB, so ratio=4 turns 8 dB over into 2 dB over.
+// A big ratio (e.g. 20 or more) makes this act as a limiter.
+//
+// The level is followed by a peak envelope that rises over 'attack' and falls over 'release', and all channels
+// share it so the stereo image doesn't shift. Short attacks catch transients better but can distort low frequencies.
+//
+// Compression only turns sound down, so ApplyGain can be used after it to make up the lost loudness.
+// Like SetStereoWidth, the compressor is applied to a copy of the data, so other sounds sharing the data are not affected.
+// Returns ErrSoundClosed if the sound is closed, or any error from replacing the player.
+//
+// Panics if the sound is not in-memory, if thresholdDb>0, if ratio<1, or if attack or release are negative
+func ApplyCompressor(s *Sound, thresholdDb, ratio float64, attack, release time.Duration) error {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be compressed")
+	}
+
+	validateCompressorArgs(thresholdDb, ratio, attack, release)
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	newSb := &SoundBuffer{
+		Data: compressPCM16(s.Data.(*SoundBuffer).Data, int(ChanCount), float64(SamplingRate), thresholdDb, ratio, attack, release),
+		Pos:  s.currBytePos(),
+	}
+
+	return s.replaceData(newSb)
+}
+
+func validateCompressorArgs(thresholdDb, ratio float64, attack, release time.Duration) {
+
+	if thresholdDb > 0 {
+		panic("compressor threshold can not be bigger than 0 dBFS")
+	}
+
+	if ratio < 1 {
+		panic("compressor ratio can not be less than one")
+	}
+
+	if attack < 0 || release < 0 {
+		panic("compressor attack and release can not be negative")
+	}
+}
+
+// CompressorEffect is the Effect version of ApplyCompressor, and panics on use if its fields are invalid like ApplyCompressor
+type CompressorEffect struct {
+	ThresholdDb float64
+	Ratio       float64
+	Attack      time.Duration
+	Release     time.Duration
+}
+
+func (e CompressorEffect) Process(pcm []byte) []byte {
+	validateCompressorArgs(e.ThresholdDb, e.Ratio, e.Attack, e.Release)
+	return compressPCM16(pcm, int(ChanCount), float64(SamplingRate), e.ThresholdDb, e.Ratio, e.Attack, e.Release)
+}
+
+// compressPCM16 returns a compressed copy of the interleaved PCM16 data. See ApplyCompressor
+func compressPCM16(pcm []byte, chanCount int, sampleRate, thresholdDb, ratio float64, attack, release time.Duration) []byte {
+
+	out := make([]byte, len(pcm))
+	if chanCount <= 0 {
+		copy(out, pcm)
+		return out
+	}
+
+	attackCoeff := envelopeCoeff(attack, sampleRate)
+	releaseCoeff := envelopeCoeff(release, sampleRate)
+	slope := 1 - 1/ratio
+
+	frameSize := chanCount * 2
+	env := 0.0
+	for i := 0; i+frameSize <= len(pcm); i += frameSize {
+
+		// The loudest channel drives the envelope
+		peak := 0.0
+		for c := 0; c < chanCount; c++ {
+			peak = math.Max(peak, math.Abs(float64(getPCM16Sample(pcm, i+c*2))))
+		}
+
+		if peak > env {
+			env = attackCoeff*env + (1-attackCoeff)*peak
+		} else {
+			env = releaseCoeff*env + (1-releaseCoeff)*peak
+		}
+
+		gain := 1.0
+		if env > 0 {
+
+			levelDb := 20 * math.Log10(env/-math.MinInt16)
+			if levelDb > thresholdDb {
+				gain = math.Pow(10, (thresholdDb-levelDb)*slope/20)
+			}
+		}
+
+		for c := 0; c < chanCount; c++ {
+			putPCM16Sample(out, i+c*2, saturateToI16(float64(getPCM16Sample(pcm, i+c*2))*gain))
+		}
+	}
+
+	return out
+}
+
+// envelopeCoeff returns the one-pole smoothing coefficient that makes an envelope move about 63% of the way to a new level within d.
+// d=0 gives 0, which makes the envelope follow the input instantly
+func envelopeCoeff(d time.Duration, sampleRate float64) float64 {
+
+	if d <= 0 {
+		return 0
+	}
+
+	return math.Exp(-1 / (d.Seconds() * sampleRate))
+}
@@ -0,0 +1,57 @@
+package wavy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCompressor(t *testing.T) {
+
+	// Half a second of a quiet 1 kHz stereo sine followed by a loud transient of the same length
+	const frames = 44100
+	pcm := make([]byte, frames*4)
+	for i := 0; i < frames; i++ {
+
+		amp := 1000.0
+		if i >= frames/2 {
+			amp = 30000
+		}
+
+		x := int16(math.Sin(2*math.Pi*1000*float64(i)/44100) * amp)
+		putPCM16Sample(pcm, i*4, x)
+		putPCM16Sample(pcm, i*4+2, x)
+	}
+
+	out := compressPCM16(pcm, 2, 44100, -20, 4, 5*time.Millisecond, 100*time.Millisecond)
+
+	// The quiet part is below the threshold so it must not change
+	quietRatio := pcm16RMS(out[:frames/2*4]) / pcm16RMS(pcm[:frames/2*4])
+	if math.Abs(quietRatio-1) > 0.01 {
+		t.Errorf("Expected quiet part to be unchanged but RMS ratio is %f\n", quietRatio)
+	}
+
+	// After the attack the loud part is about 19 dB over the threshold, which a 4:1 ratio turns into about 5 dB over (~14 dB of reduction)
+	loudStart := (frames/2 + 4410) * 4
+	loudRatio := pcm16RMS(out[loudStart:]) / pcm16RMS(pcm[loudStart:])
+	reductionDb := -20 * math.Log10(loudRatio)
+	if reductionDb < 12 || reductionDb > 16 {
+		t.Errorf("Expected about 14 dB of gain reduction on the loud part but got %f dB\n", reductionDb)
+	}
+
+	// Before Init there are no channels, which must not loop forever
+	if out := compressPCM16(pcm[:8], 0, 0, -20, 4, 5*time.Millisecond, 100*time.Millisecond); string(out) != string(pcm[:8]) {
+		t.Errorf("Expected compressing with no channels to return the data unchanged\n")
+	}
+}
+
+func TestCompressorClosed(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Second)
+	s.Data = nil
+
+	if err := ApplyCompressor(s, -20, 4, time.Millisecond, 50*time.Millisecond); err != ErrSoundClosed {
+		t.Errorf("Expected ErrSoundClosed when compressing a closed sound but got '%v'\n", err)
+		return
+	}
+}
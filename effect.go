@@ -0,0 +1,46 @@
+package wavy
+
+// Effect processes PCM in the format wavy was initialized with (see Init), and is used to chain processing (see ApplyEffects)
+// or to apply the same processing to many sounds (see SetGroupEffect).
+// The built-in effects are the Effect versions of the functions that change sound data, like GainEffect for ApplyGain and LowPassEffect for ApplyLowPass.
+//
+// Process gets a whole sound and returns the processed sound, which can have a different length (e.g. to fit an echo tail).
+// It may modify and return pcm instead of allocating
+type Effect interface {
+	Process(pcm []byte) []byte
+}
+
+var (
+	_ Effect = GainEffect{}
+	_ Effect = StereoWidthEffect{}
+	_ Effect = LowPassEffect{}
+	_ Effect = HighPassEffect{}
+	_ Effect = BandPassEffect{}
+	_ Effect = NotchEffect{}
+	_ Effect = EQEffect{}
+	_ Effect = CompressorEffect{}
+)
+
+// ApplyEffects returns a copy of an in-memory sound (like CopyInMemSound) whose data is the data of s processed by the effects in order.
+// s is not changed. For example, ApplyEffects(s, HighPassEffect{80, 0.7071}, CompressorEffect{-18, 4, 5 * time.Millisecond, 100 * time.Millisecond}, GainEffect{1.5})
+// cleans up the low end, levels and then boosts a voice line.
+//
+// Panics if the sound is not in-memory
+func ApplyEffects(s *Sound, effects ...Effect) *Sound {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can have effects applied")
+	}
+
+	srcData := s.Data.(*SoundBuffer).Data
+	pcm := make([]byte, len(srcData))
+	copy(pcm, srcData)
+
+	for _, effect := range effects {
+		pcm = effect.Process(pcm)
+	}
+
+	newSound := copyInMemSoundWithData(s, &SoundBuffer{Data: pcm})
+	newSound.Info.Size = int64(len(pcm))
+	return newSound
+}
@@ -0,0 +1,61 @@
+package wavy
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEffects(t *testing.T) {
+
+	oldSamplingRate, oldChanCount := SamplingRate, ChanCount
+	SamplingRate, ChanCount = SampleRate_44100, SoundChannelCount_2
+	defer func() { SamplingRate, ChanCount = oldSamplingRate, oldChanCount }()
+
+	// A tenth of a second of a stereo 1 kHz sine
+	pcm := make([]byte, 4410*4)
+	for i := 0; i < 4410; i++ {
+		x := int16(math.Sin(2*math.Pi*1000*float64(i)/44100) * 16000)
+		putPCM16Sample(pcm, i*4, x)
+		putPCM16Sample(pcm, i*4+2, -x)
+	}
+
+	// Each built-in effect must match the function it's the Effect version of
+	tests := []struct {
+		name     string
+		effect   Effect
+		expected []byte
+	}{
+		{name: "Gain", effect: GainEffect{Gain: 0.5}, expected: applyGainPCM16(pcm, 0.5)},
+		{name: "StereoWidth", effect: StereoWidthEffect{Width: 0}, expected: stereoWidthPCM16(pcm, 0)},
+		{name: "LowPass", effect: LowPassEffect{CutoffHz: 200, Q: 0.7071}, expected: filterPCM16(pcm, 2, []biquad{newLowPassBiquad(200, 0.7071)})},
+		{name: "HighPass", effect: HighPassEffect{CutoffHz: 200, Q: 0.7071}, expected: filterPCM16(pcm, 2, []biquad{newHighPassBiquad(200, 0.7071)})},
+		{name: "BandPass", effect: BandPassEffect{CenterHz: 1000, Q: 2}, expected: filterPCM16(pcm, 2, []biquad{newBandPassBiquad(1000, 2)})},
+		{name: "Notch", effect: NotchEffect{CenterHz: 1000, Q: 2}, expected: filterPCM16(pcm, 2, []biquad{newNotchBiquad(1000, 2)})},
+		{name: "EQ", effect: EQEffect{Bands: []EQBand{{100, 0.7, 3}, {1000, 0.7, -6}}}, expected: filterPCM16(pcm, 2, []biquad{newPeakingBiquad(100, 0.7, 3), newPeakingBiquad(1000, 0.7, -6)})},
+		{name: "Compressor", effect: CompressorEffect{ThresholdDb: -12, Ratio: 4, Attack: time.Millisecond, Release: 50 * time.Millisecond}, expected: compressPCM16(pcm, 2, 44100, -12, 4, time.Millisecond, 50*time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			if got := tt.effect.Process(pcm); !bytes.Equal(got, tt.expected) {
+				t.Errorf("Expected effect output to match the function it wraps\n")
+				return
+			}
+		})
+	}
+
+	if rms := pcm16RMS(EQEffect{}.Process(pcm)); rms != pcm16RMS(pcm) {
+		t.Errorf("Expected an EQ with no bands to not change the sound\n")
+		return
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected an invalid effect to panic on use\n")
+		}
+	}()
+	LowPassEffect{CutoffHz: 30000, Q: 0.7}.Process(pcm)
+}
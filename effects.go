@@ -0,0 +1,200 @@
+package wavy
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	MaxStereoWidth = 4
+)
+
+// SetStereoWidth widens or narrows the stereo image of an in-memory sound using mid-side processing.
+// For every frame the mid and side signals are computed as M = (L+R)/2 and S = (L-R)/2,
+// then S is scaled by width and the channels are rebuilt with L = M+S and R = M-S.
+//
+// width=0 produces mono, width=1 keeps the original, and width>1 makes the sound wider.
+// width is clamped to [0, MaxStereoWidth], and samples that go out of range are saturated.
+//
+// The transform is applied to a copy of the data, so other sounds sharing the same data (e.g. from CopyInMemSound) are not affected.
+// Because the data itself is changed repeated calls stack.
+//
+// This is a no-op if the context is not stereo (ChanCount!=2).
+// Returns ErrSoundClosed if the sound is closed, or any error from replacing the player.
+// Panics if the sound is not in-memory
+func (s *Sound) SetStereoWidth(width float64) error {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can have their stereo width changed")
+	}
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	if ChanCount != SoundChannelCount_2 {
+		return nil
+	}
+
+	newSb := &SoundBuffer{
+		Data: stereoWidthPCM16(s.Data.(*SoundBuffer).Data, width),
+		Pos:  s.currBytePos(),
+	}
+
+	return s.replaceData(newSb)
+}
+
+// stereoWidthPCM16 returns a copy of the stereo PCM16 data with its width changed. See SetStereoWidth
+func stereoWidthPCM16(pcm []byte, width float64) []byte {
+
+	if width < 0 {
+		width = 0
+	} else if width > MaxStereoWidth {
+		width = MaxStereoWidth
+	}
+
+	newData := make([]byte, len(pcm))
+	copy(newData, pcm)
+
+	// Each frame is 4 bytes: a 16-bit left sample followed by a 16-bit right sample
+	for i := 0; i+3 < len(newData); i += 4 {
+
+		l := float64(getPCM16Sample(newData, i))
+		r := float64(getPCM16Sample(newData, i+2))
+
+		mid := (l + r) / 2
+		side := (l - r) / 2 * width
+
+		putPCM16Sample(newData, i, saturateToI16(mid+side))
+		putPCM16Sample(newData, i+2, saturateToI16(mid-side))
+	}
+
+	return newData
+}
+
+// StereoWidthEffect is the Effect version of SetStereoWidth, and like it does nothing if the context is not stereo
+type StereoWidthEffect struct {
+	Width float64
+}
+
+func (e StereoWidthEffect) Process(pcm []byte) []byte {
+
+	if ChanCount != SoundChannelCount_2 {
+		return pcm
+	}
+
+	return stereoWidthPCM16(pcm, e.Width)
+}
+
+// ApplyGain multiplies every sample of an in-memory sound by gain, which bakes the volume into the data
+// (e.g. to layer copies at fixed levels). Samples that go out of range are saturated.
+// gain=1 keeps the sound as is, gain=0.5 is about -6 dB and gain=2 is about +6 dB.
+//
+// Like SetStereoWidth, the gain is applied to a copy of the data so other sounds sharing the data are not affected.
+// Returns ErrSoundClosed if the sound is closed, or any error from replacing the player.
+// Panics if the sound is not in-memory or if gain<0
+func ApplyGain(s *Sound, gain float64) error {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can have gain applied")
+	}
+
+	if gain < 0 {
+		panic("gain can not be less than zero")
+	}
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	newSb := &SoundBuffer{
+		Data: applyGainPCM16(s.Data.(*SoundBuffer).Data, gain),
+		Pos:  s.currBytePos(),
+	}
+
+	return s.replaceData(newSb)
+}
+
+// GainEffect is the Effect version of ApplyGain, and panics on use if Gain<0.
+// It only processes the PCM it's given, so unlike ApplyGain it has no sound or player that can fail, and errors
+// from applying it to a sound (e.g. a closed sound) are returned by whatever applies it, like ApplyEffects or SetGroupEffect
+type GainEffect struct {
+	Gain float64
+}
+
+func (e GainEffect) Process(pcm []byte) []byte {
+
+	if e.Gain < 0 {
+		panic("gain can not be less than zero")
+	}
+
+	return applyGainPCM16(pcm, e.Gain)
+}
+
+// gainFracBits is the number of fraction bits of the fixed-point gain used by applyGainPCM16
+const gainFracBits = 16
+
+// applyGainPCM16 returns a copy of pcm with every sample multiplied by gain. To avoid per-sample float math
+// the gain is converted to fixed-point once, and each sample is scaled with an integer multiply and shift
+func applyGainPCM16(pcm []byte, gain float64) []byte {
+
+	fixedGain := int64(math.Round(gain * (1 << gainFracBits)))
+	out := make([]byte, len(pcm))
+	for i := 0; i+1 < len(pcm); i += 2 {
+
+		// Adding half before the shift rounds to nearest instead of towards negative infinity
+		x := (int64(getPCM16Sample(pcm, i))*fixedGain + 1<<(gainFracBits-1)) >> gainFracBits
+		if x > math.MaxInt16 {
+			x = math.MaxInt16
+		} else if x < math.MinInt16 {
+			x = math.MinInt16
+		}
+
+		putPCM16Sample(out, i, int16(x))
+	}
+
+	return out
+}
+
+// effectState holds the parameters that change how a sound plays without being part of its data.
+// This is what gets carried over when a sound is copied, so new parameters of that kind should be added here
+type effectState struct {
+	volume float64
+}
+
+// effects captures the current effect state of the sound
+func (s *Sound) effects() effectState {
+	return effectState{
+		volume: s.Volume(),
+	}
+}
+
+// applyTo sets the effect state on the sound
+func (e effectState) applyTo(s *Sound) {
+	s.Player.SetVolume(e.volume)
+}
+
+// getPCM16Sample returns the signed 16-bit sample that starts at byteIndex
+func getPCM16Sample(pcm []byte, byteIndex int) int16 {
+	return int16(binary.LittleEndian.Uint16(pcm[byteIndex:]))
+}
+
+// putPCM16Sample writes the signed 16-bit sample x starting at byteIndex
+func putPCM16Sample(pcm []byte, byteIndex int, x int16) {
+	binary.LittleEndian.PutUint16(pcm[byteIndex:], uint16(x))
+}
+
+// saturateToI16 rounds x and clamps it to the int16 range
+func saturateToI16(x float64) int16 {
+
+	x = math.Round(x)
+	if x < math.MinInt16 {
+		return math.MinInt16
+	}
+
+	if x > math.MaxInt16 {
+		return math.MaxInt16
+	}
+
+	return int16(x)
+}
@@ -0,0 +1,84 @@
+package wavy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// applyGainPCM16Float is the straightforward float implementation of applyGainPCM16, used as a reference
+func applyGainPCM16Float(pcm []byte, gain float64) []byte {
+
+	out := make([]byte, len(pcm))
+	for i := 0; i+1 < len(pcm); i += 2 {
+		putPCM16Sample(out, i, saturateToI16(float64(getPCM16Sample(pcm, i))*gain))
+	}
+
+	return out
+}
+
+func makeGainTestPCM() []byte {
+
+	pcm := make([]byte, 2*(math.MaxUint16+1))
+	for i := 0; i <= math.MaxUint16; i++ {
+		putPCM16Sample(pcm, i*2, int16(i+math.MinInt16))
+	}
+
+	return pcm
+}
+
+func TestApplyGainPCM16(t *testing.T) {
+
+	// Every possible sample value
+	pcm := makeGainTestPCM()
+	for _, gain := range []float64{0, 0.25, 0.5, 0.7071, 1, 1.5, 2, 8} {
+
+		fixed := applyGainPCM16(pcm, gain)
+		float := applyGainPCM16Float(pcm, gain)
+		for i := 0; i < len(pcm); i += 2 {
+
+			// The fixed-point gain can be off by a tiny amount, which can change the rounding by one
+			diff := int(getPCM16Sample(fixed, i)) - int(getPCM16Sample(float, i))
+			if diff < -1 || diff > 1 {
+				t.Errorf("Gain %f: expected sample %d to be %d but got %d\n", gain, getPCM16Sample(pcm, i), getPCM16Sample(float, i), getPCM16Sample(fixed, i))
+				return
+			}
+		}
+	}
+
+	// Saturation
+	loud := applyGainPCM16(pcm, 4)
+	if getPCM16Sample(loud, 0) != math.MinInt16 || getPCM16Sample(loud, len(loud)-2) != math.MaxInt16 {
+		t.Errorf("Expected samples to saturate instead of wrapping\n")
+	}
+}
+
+func BenchmarkApplyGainPCM16(b *testing.B) {
+
+	pcm := makeGainTestPCM()
+	b.SetBytes(int64(len(pcm)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		applyGainPCM16(pcm, 0.8)
+	}
+}
+
+func BenchmarkApplyGainPCM16Float(b *testing.B) {
+
+	pcm := makeGainTestPCM()
+	b.SetBytes(int64(len(pcm)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		applyGainPCM16Float(pcm, 0.8)
+	}
+}
+
+func TestApplyGainClosed(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Second)
+	s.Data = nil
+	if err := ApplyGain(s, 0.5); err != ErrSoundClosed {
+		t.Errorf("Expected ErrSoundClosed when applying gain to a closed sound but got '%v'\n", err)
+		return
+	}
+}
@@ -0,0 +1,161 @@
+package wavy
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// finishPollInterval is how often goroutines waiting for a sound to finish check on it, when they can't just use Wait
+	finishPollInterval = 10 * time.Millisecond
+
+	// playerStartTimeout is the longest PlayTogether waits for players to fill their first buffer before resuming the context
+	playerStartTimeout = time.Second
+
+	// SoundEventsBufferSize is the number of events SoundEvents() can hold before new events start getting dropped
+	SoundEventsBufferSize = 256
+)
+
+// SoundEvent describes something that happened to a sound.
+// SoundID is the ID() of the sound, and can be used to tell which sound the event belongs to
+type SoundEvent struct {
+	Type    SoundEventType
+	SoundID uint64
+	Time    time.Time
+}
+
+var (
+	soundEvents = make(chan SoundEvent, SoundEventsBufferSize)
+	lastSoundID uint64
+)
+
+// SoundEvents returns a channel that receives the events of all sounds, which is useful
+// when a single place needs to react to sounds finishing instead of waiting on each one.
+//
+// The channel is shared by all callers and holds up to SoundEventsBufferSize events.
+// Publishing never blocks playback, so if the consumer is too slow and the buffer is full then new events are dropped.
+// If nobody reads the channel it simply fills up and further events are discarded
+func SoundEvents() <-chan SoundEvent {
+	return soundEvents
+}
+
+// ID returns a number that uniquely identifies this sound for the lifetime of the program.
+// IDs are assigned on first use, and copies of a sound get their own ID
+func (s *Sound) ID() uint64 {
+
+	id := atomic.LoadUint64(&s.id)
+	if id != 0 {
+		return id
+	}
+
+	atomic.CompareAndSwapUint64(&s.id, 0, atomic.AddUint64(&lastSoundID, 1))
+	return atomic.LoadUint64(&s.id)
+}
+
+// publishEvent updates the stats of the sound and sends the event on the SoundEvents channel
+func (s *Sound) publishEvent(t SoundEventType) {
+
+	s.recordStats(t)
+
+	select {
+	case soundEvents <- SoundEvent{Type: t, SoundID: s.ID(), Time: clk.Now()}:
+	default:
+	}
+}
+
+// watchForFinish publishes a finished event once the sound stops at its end.
+// Only one watcher runs per sound at a time, so calling play repeatedly doesn't produce duplicate events
+func (s *Sound) watchForFinish() {
+
+	if !atomic.CompareAndSwapInt32(&s.finishWatched, 0, 1) {
+		return
+	}
+
+	go func() {
+
+		s.Wait()
+		atomic.StoreInt32(&s.finishWatched, 0)
+		s.stopPlayTimer()
+
+		if !s.IsClosed() && !s.IsLooping && s.currBytePos() >= s.endBytePos() {
+			s.publishEvent(SoundEventType_Finished)
+		}
+	}()
+}
+
+// PlayAndClose plays the sound and closes it once it finishes, which is convenient for fire-and-forget sounds.
+// If the sound is looping (e.g. LoopAsync was called before this) then it is closed once looping ends and the last pass finishes.
+//
+// Pausing doesn't close the sound, and it is closed when it finishes after being resumed.
+// The sound must not be used after it finishes, but closing it early is fine
+func (s *Sound) PlayAndClose() {
+
+	if !s.IsPlaying() {
+		s.PlayAsync()
+	}
+
+	go func() {
+
+		for !s.IsClosed() {
+
+			s.Wait()
+			if s.IsClosed() {
+				return
+			}
+
+			if !s.IsLooping && !s.IsPlaying() && s.currBytePos() >= s.endBytePos() {
+				s.Close()
+				return
+			}
+
+			clk.Sleep(finishPollInterval)
+		}
+	}()
+}
+
+// SetOnPlay sets a function that is called every time the sound is told to play (e.g. with PlayAsync or LoopAsync), right before playing starts.
+// It is not called when a loop repeats, since that's not a new play. This is useful for things like centralized logging or enforcing mixing rules.
+//
+// The hook runs synchronously on the goroutine that called play, so it should be fast and must not call play on the same sound.
+// Passing nil removes the hook
+func (s *Sound) SetOnPlay(f func()) {
+
+	s.hooksLock.Lock()
+	defer s.hooksLock.Unlock()
+
+	s.onPlay = f
+}
+
+// SetOnPause sets a function that is called every time the sound is paused, including pauses done by functions like Stop and PauseFade.
+//
+// The hook runs synchronously on the goroutine that called pause, right after pausing, so it should be fast and must not pause the same sound.
+// Passing nil removes the hook
+func (s *Sound) SetOnPause(f func()) {
+
+	s.hooksLock.Lock()
+	defer s.hooksLock.Unlock()
+
+	s.onPause = f
+}
+
+func (s *Sound) callOnPlay() {
+
+	s.hooksLock.Lock()
+	f := s.onPlay
+	s.hooksLock.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
+
+func (s *Sound) callOnPause() {
+
+	s.hooksLock.Lock()
+	f := s.onPause
+	s.hooksLock.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
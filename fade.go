@@ -0,0 +1,221 @@
+package wavy
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// fadeStepDuration is how often the volume is updated during a fade
+	fadeStepDuration = 10 * time.Millisecond
+
+	// duckPollInterval is how often Duck checks whether the trigger sound stopped
+	duckPollInterval = 10 * time.Millisecond
+
+	// fadeMinDecibels is the volume exponential fades treat as silence, since zero is -inf decibels
+	fadeMinDecibels = -60
+)
+
+// FadeTo gradually changes the volume from its current value to 'volume' over d, without blocking.
+// Starting another fade or calling SetVolume cancels a running fade, leaving the volume wherever it got to.
+// If d<=0 the volume is changed immediately.
+//
+// The shape of the fade can optionally be passed, and is FadeCurve_Linear by default.
+//
+// Volume must be between 0 and 1 (both inclusive), otherwise this panics like SetVolume
+func (s *Sound) FadeTo(volume float64, d time.Duration, curve ...FadeCurve) {
+	s.fadeToCurve(volume, d, optionalFadeCurve(curve))
+}
+
+// FadeIn fades the volume from 0 to 1 over d. It doesn't start playback, so it is usually called right before PlayAsync
+func (s *Sound) FadeIn(d time.Duration, curve ...FadeCurve) {
+	s.SetVolume(0)
+	s.fadeToCurve(1, d, optionalFadeCurve(curve))
+}
+
+// FadeOut fades the volume from its current value to 0 over d. The sound keeps playing silently, see PauseFade to also pause it
+func (s *Sound) FadeOut(d time.Duration, curve ...FadeCurve) {
+	s.fadeToCurve(0, d, optionalFadeCurve(curve))
+}
+
+// optionalFadeCurve returns the first curve if one was passed, and FadeCurve_Linear otherwise
+func optionalFadeCurve(curve []FadeCurve) FadeCurve {
+
+	if len(curve) == 0 {
+		return FadeCurve_Linear
+	}
+
+	return curve[0]
+}
+
+// PauseFade fades the volume out over d then pauses the sound, which avoids the click an abrupt pause can make.
+// Once paused the volume is set back to what it was before the fade, so a later play isn't silent.
+// This doesn't block, and if another fade starts (or SetVolume is called) before this one ends then the sound isn't paused
+func (s *Sound) PauseFade(d time.Duration) {
+	s.pauseFade(d)
+}
+
+// pauseFade is PauseFade but returns a channel that receives true once the sound is paused, or false if the fade was cancelled
+func (s *Sound) pauseFade(d time.Duration) <-chan bool {
+
+	paused := make(chan bool, 1)
+	origVol := s.Volume()
+	faded := s.fadeTo(0, d)
+
+	go func() {
+
+		if !<-faded {
+			paused <- false
+			return
+		}
+
+		s.Pause()
+		s.Player.SetVolume(origVol)
+		paused <- true
+	}()
+
+	return paused
+}
+
+// ResumeFade starts playing the sound at zero volume then fades in to the current volume over d
+func (s *Sound) ResumeFade(d time.Duration) {
+	s.resumeFade(d)
+}
+
+// resumeFade is ResumeFade but returns the channel of the fade
+func (s *Sound) resumeFade(d time.Duration) <-chan bool {
+
+	vol := s.Volume()
+	s.cancelFade()
+	s.Player.SetVolume(0)
+	s.PlayAsync()
+
+	return s.fadeTo(vol, d)
+}
+
+// fadeTo starts a linear fade and returns a channel that receives true if the fade completes, or false if it gets cancelled
+func (s *Sound) fadeTo(volume float64, d time.Duration) <-chan bool {
+	return s.fadeToCurve(volume, d, FadeCurve_Linear)
+}
+
+// fadeToCurve is fadeTo with a custom curve
+func (s *Sound) fadeToCurve(volume float64, d time.Duration, curve FadeCurve) <-chan bool {
+
+	if volume < 0 || volume > 1 {
+		panic("sound volume can not be less than zero or bigger than one")
+	}
+
+	done := make(chan bool, 1)
+	gen := atomic.AddUint64(&s.fadeGen, 1)
+	if d <= 0 {
+		s.Player.SetVolume(volume)
+		done <- true
+		return done
+	}
+
+	startVol := s.Volume()
+	steps := int(d / fadeStepDuration)
+	if steps < 1 {
+		steps = 1
+	}
+
+	go func() {
+
+		for i := 1; i <= steps; i++ {
+
+			clk.Sleep(d / time.Duration(steps))
+			if atomic.LoadUint64(&s.fadeGen) != gen || s.IsClosed() {
+				done <- false
+				return
+			}
+
+			s.Player.SetVolume(fadeVolumeAt(curve, startVol, volume, float64(i)/float64(steps)))
+		}
+
+		done <- true
+	}()
+
+	return done
+}
+
+// fadeVolumeAt returns the volume of a fade from 'from' to 'to' at t, where t is in [0, 1]
+func fadeVolumeAt(curve FadeCurve, from, to, t float64) float64 {
+
+	if t >= 1 {
+		return to
+	}
+
+	switch curve {
+	case FadeCurve_Exponential:
+
+		// Interpolate in decibels, treating anything below fadeMinDecibels as silence
+		fromDb := volumeToDecibels(from)
+		toDb := volumeToDecibels(to)
+		db := fromDb + (toDb-fromDb)*t
+		if db <= fadeMinDecibels {
+			return 0
+		}
+		return math.Pow(10, db/20)
+
+	case FadeCurve_SCurve:
+		// Smoothstep
+		t = t * t * (3 - 2*t)
+	}
+
+	return from + (to-from)*t
+}
+
+func volumeToDecibels(volume float64) float64 {
+
+	if volume <= 0 {
+		return fadeMinDecibels
+	}
+
+	return math.Max(20*math.Log10(volume), fadeMinDecibels)
+}
+
+// cancelFade stops any running fade
+func (s *Sound) cancelFade() {
+	atomic.AddUint64(&s.fadeGen, 1)
+}
+
+// Duck lowers the volume of 'music' by 'amount' while 'trigger' is playing, then restores it once 'trigger' stops playing and looping.
+// amount is relative to the current volume, so amount=0.75 with a volume of 0.8 ducks to 0.2.
+// The volume fades down over 'attack' and back up over 'release'.
+//
+// Duck is meant to be called right after starting the trigger, and returns immediately.
+// Overlapping ducks on the same music are refcounted, so the music stays ducked until the last trigger stops.
+// While ducked only the first duck decides the ducked volume, and once the last trigger stops the volume
+// goes back to what it was before the first duck, replacing any volume changes made in between.
+//
+// Panics if amount is not between 0 and 1 (both inclusive)
+func Duck(music *Sound, trigger *Sound, amount float64, attack, release time.Duration) {
+
+	if amount < 0 || amount > 1 {
+		panic("duck amount can not be less than zero or bigger than one")
+	}
+
+	music.duckLock.Lock()
+	if music.duckCount == 0 {
+		music.duckRestoreVolume = music.Volume()
+		music.fadeTo(music.duckRestoreVolume*(1-amount), attack)
+	}
+	music.duckCount++
+	music.duckLock.Unlock()
+
+	go func() {
+
+		for trigger.IsPlaying() || trigger.IsLooping {
+			clk.Sleep(duckPollInterval)
+		}
+
+		music.duckLock.Lock()
+		defer music.duckLock.Unlock()
+
+		music.duckCount--
+		if music.duckCount == 0 && !music.IsClosed() {
+			music.fadeTo(music.duckRestoreVolume, release)
+		}
+	}()
+}
@@ -0,0 +1,233 @@
+package wavy
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFadeTo(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Second)
+	s.SetVolume(1)
+
+	if completed := <-s.fadeTo(0.5, 100*time.Millisecond); !completed {
+		t.Errorf("Expected fade to complete\n")
+		return
+	}
+
+	if s.Volume() != 0.5 {
+		t.Errorf("Expected volume to be 0.5 after fading but got %f\n", s.Volume())
+		return
+	}
+
+	// A new fade cancels the running one
+	first := s.fadeTo(0, time.Hour)
+	second := s.fadeTo(1, 0)
+	if <-first || !<-second {
+		t.Errorf("Expected the first fade to be cancelled by the second\n")
+		return
+	}
+}
+
+func TestFadeCurves(t *testing.T) {
+
+	curves := []FadeCurve{FadeCurve_Linear, FadeCurve_Exponential, FadeCurve_SCurve}
+	for _, curve := range curves {
+
+		if v := fadeVolumeAt(curve, 0.2, 0.9, 0); math.Abs(v-0.2) > 1e-9 {
+			t.Errorf("Expected %s fade to start at 0.2 but got %f\n", curve, v)
+		}
+
+		if v := fadeVolumeAt(curve, 0.2, 0.9, 1); v != 0.9 {
+			t.Errorf("Expected %s fade to end at 0.9 but got %f\n", curve, v)
+		}
+
+		// Fades must move in one direction only
+		prev := 1.0
+		for i := 0; i <= 100; i++ {
+
+			v := fadeVolumeAt(curve, 1, 0, float64(i)/100)
+			if v > prev || v < 0 {
+				t.Errorf("Expected %s fade out to decrease within [0, 1] but got %f after %f at t=%f\n", curve, v, prev, float64(i)/100)
+				break
+			}
+			prev = v
+		}
+	}
+
+	// Halfway through an exponential fade out from full volume is -30dB
+	if v := fadeVolumeAt(FadeCurve_Exponential, 1, 0, 0.5); math.Abs(v-math.Pow(10, -1.5)) > 1e-9 {
+		t.Errorf("Expected exponential fade to be at -30dB halfway but got %f\n", v)
+	}
+
+	if v := fadeVolumeAt(FadeCurve_SCurve, 0, 1, 0.25); v >= 0.25 {
+		t.Errorf("Expected s-curve fade to start slower than linear but got %f at t=0.25\n", v)
+	}
+
+	s, _, _ := newFakeSound(t, time.Second)
+	s.SetVolume(0)
+	if completed := <-s.fadeToCurve(0.5, 100*time.Millisecond, FadeCurve_Exponential); !completed || s.Volume() != 0.5 {
+		t.Errorf("Expected exponential fade to complete at 0.5 but got %f\n", s.Volume())
+	}
+}
+
+func TestSeekFade(t *testing.T) {
+
+	SetSeekFade(DefaultSeekFadeDuration)
+	defer SetSeekFade(0)
+
+	// The seek fade is installed up front because installing it creates a real player
+	s, _, fc := newFakeSound(t, time.Hour)
+	s.seekFade = newSeekFadeSource()
+	s.seekFade.Source = s.Data
+	s.SetVolume(0.8)
+	s.Player.Play()
+
+	s.SeekToPercent(0.5)
+	if fc.Elapsed() != 0 || s.Volume() != 0.8 {
+		t.Errorf("Expected seek fade to not sleep or change the volume, but it slept %s and the volume is %f\n", fc.Elapsed(), s.Volume())
+		return
+	}
+
+	if s.seekFade.pendingPos != s.Info.Size/2 {
+		t.Errorf("Expected a faded seek to %d to be requested but got %d\n", s.Info.Size/2, s.seekFade.pendingPos)
+		return
+	}
+
+	// Seeking a paused sound doesn't fade
+	s.Pause()
+	s.seekFade.pendingPos = -1
+	s.SeekToPercent(0.25)
+	if s.seekFade.pendingPos != -1 {
+		t.Errorf("Expected seeking a paused sound to not fade\n")
+		return
+	}
+}
+
+func TestSeekFadeSource(t *testing.T) {
+
+	oldBytesPerSample := BytesPerSample
+	defer func() { BytesPerSample = oldBytesPerSample }()
+	BytesPerSample = 2
+
+	// Mono data where each half has a different constant value
+	data := make([]byte, 2000)
+	for i := 0; i < len(data); i += 2 {
+		if i < len(data)/2 {
+			putPCM16Sample(data, i, 1000)
+		} else {
+			putPCM16Sample(data, i, -1000)
+		}
+	}
+
+	fs := newSeekFadeSource()
+	fs.Source = &SoundBuffer{Data: data}
+	fs.requestSeek(1000, 20)
+
+	// The first read fades out the current audio to silence
+	buf := make([]byte, 100)
+	n, err := fs.Read(buf)
+	if n != 20 || err != nil {
+		t.Errorf("Expected fade out read of 20 bytes but got %d bytes and err '%v'\n", n, err)
+		return
+	}
+
+	if getPCM16Sample(buf, 0) != 900 || getPCM16Sample(buf, 18) != 0 {
+		t.Errorf("Expected fade out to go from 900 to 0 but got %d to %d\n", getPCM16Sample(buf, 0), getPCM16Sample(buf, 18))
+		return
+	}
+
+	// Then the audio after the seek fades in from silence
+	n, _ = fs.Read(buf)
+	if n != 100 || getPCM16Sample(buf, 0) != 0 || getPCM16Sample(buf, 10) != -500 || getPCM16Sample(buf, 20) != -1000 || getPCM16Sample(buf, 98) != -1000 {
+		t.Errorf("Expected fade in from 0 to -1000 after the seek position, but got %d, %d, %d\n", getPCM16Sample(buf, 0), getPCM16Sample(buf, 10), getPCM16Sample(buf, 20))
+		return
+	}
+
+	// Direct seeks cancel requested fades
+	fs.requestSeek(0, 20)
+	fs.Seek(1500, io.SeekStart)
+	n, _ = fs.Read(buf)
+	if n != 100 || getPCM16Sample(buf, 0) != -1000 {
+		t.Errorf("Expected direct seek to cancel the fade, but got %d bytes starting with %d\n", n, getPCM16Sample(buf, 0))
+		return
+	}
+}
+
+func TestPauseResumeFade(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Hour)
+	s.SetVolume(0.8)
+	s.PlayAsync()
+
+	if paused := <-s.pauseFade(100 * time.Millisecond); !paused {
+		t.Errorf("Expected PauseFade to pause the sound\n")
+		return
+	}
+
+	if s.IsPlaying() || s.Volume() != 0.8 {
+		t.Errorf("Expected sound to be paused with its volume restored to 0.8 but got playing=%v and volume=%f\n", s.IsPlaying(), s.Volume())
+		return
+	}
+
+	faded := s.resumeFade(100 * time.Millisecond)
+	if !s.IsPlaying() {
+		t.Errorf("Expected ResumeFade to start playing\n")
+		return
+	}
+
+	if completed := <-faded; !completed || s.Volume() != 0.8 {
+		t.Errorf("Expected ResumeFade to fade in to 0.8 but got %f\n", s.Volume())
+		return
+	}
+}
+
+func TestDuckRefcount(t *testing.T) {
+
+	music, _, _ := newFakeSound(t, time.Second)
+	music.SetVolume(1)
+
+	trigger1 := &Sound{Player: &fakePlayer{clk: clk.(*fakeClock), playDuration: 100 * time.Millisecond}}
+	// Duck polls using the fake clock which moves fast, so trigger2 must play long enough to only stop when paused
+	trigger2 := &Sound{Player: &fakePlayer{clk: clk.(*fakeClock), playDuration: 1 << 62}}
+	trigger1.Player.Play()
+	trigger2.Player.Play()
+
+	Duck(music, trigger1, 0.5, 0, 0)
+	Duck(music, trigger2, 0.5, 0, 0)
+	if music.Volume() != 0.5 {
+		t.Errorf("Expected volume to be 0.5 while ducked but got %f\n", music.Volume())
+		return
+	}
+
+	// Only one trigger finishing should keep the music ducked
+	waitForDuckCount(music, 1)
+	if music.Volume() != 0.5 {
+		t.Errorf("Expected volume to stay at 0.5 while one trigger is still playing but got %f\n", music.Volume())
+		return
+	}
+
+	trigger2.Player.Pause()
+	waitForDuckCount(music, 0)
+	if music.Volume() != 1 {
+		t.Errorf("Expected volume to be restored to 1 after all triggers stopped but got %f\n", music.Volume())
+		return
+	}
+}
+
+func waitForDuckCount(s *Sound, count int) {
+
+	for {
+		s.duckLock.Lock()
+		currCount := s.duckCount
+		s.duckLock.Unlock()
+
+		if currCount == count {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
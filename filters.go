@@ -0,0 +1,274 @@
+package wavy
+
+import "math"
+
+// biquad is a second order IIR filter using the coefficients from the Audio EQ Cookbook by Robert Bristow-Johnson,
+// normalized so that a0=1
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+}
+
+// biquadState is the filter memory of one channel
+type biquadState struct {
+	x1, x2 float64
+	y1, y2 float64
+}
+
+func (f *biquad) process(st *biquadState, x float64) float64 {
+
+	y := f.b0*x + f.b1*st.x1 + f.b2*st.x2 - f.a1*st.y1 - f.a2*st.y2
+
+	st.x2, st.x1 = st.x1, x
+	st.y2, st.y1 = st.y1, y
+	return y
+}
+
+// newBiquad normalizes the coefficients by a0
+func newBiquad(b0, b1, b2, a0, a1, a2 float64) biquad {
+	return biquad{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b2 / a0,
+		a1: a1 / a0,
+		a2: a2 / a0,
+	}
+}
+
+// biquadParams returns cos(w0) and alpha, which most filter types are built from
+func biquadParams(freqHz, q float64) (cosW0, alpha float64) {
+	w0 := 2 * math.Pi * freqHz / float64(SamplingRate)
+	return math.Cos(w0), math.Sin(w0) / (2 * q)
+}
+
+func newLowPassBiquad(cutoffHz, q float64) biquad {
+	cosW0, alpha := biquadParams(cutoffHz, q)
+	return newBiquad((1-cosW0)/2, 1-cosW0, (1-cosW0)/2, 1+alpha, -2*cosW0, 1-alpha)
+}
+
+func newHighPassBiquad(cutoffHz, q float64) biquad {
+	cosW0, alpha := biquadParams(cutoffHz, q)
+	return newBiquad((1+cosW0)/2, -(1 + cosW0), (1+cosW0)/2, 1+alpha, -2*cosW0, 1-alpha)
+}
+
+// newBandPassBiquad has a peak gain of 0 dB at the center frequency
+func newBandPassBiquad(centerHz, q float64) biquad {
+	cosW0, alpha := biquadParams(centerHz, q)
+	return newBiquad(alpha, 0, -alpha, 1+alpha, -2*cosW0, 1-alpha)
+}
+
+func newNotchBiquad(centerHz, q float64) biquad {
+	cosW0, alpha := biquadParams(centerHz, q)
+	return newBiquad(1, -2*cosW0, 1, 1+alpha, -2*cosW0, 1-alpha)
+}
+
+// newPeakingBiquad boosts or cuts frequencies around centerHz by gainDb, leaving the rest unchanged
+func newPeakingBiquad(centerHz, q, gainDb float64) biquad {
+	a := math.Pow(10, gainDb/40)
+	cosW0, alpha := biquadParams(centerHz, q)
+	return newBiquad(1+alpha*a, -2*cosW0, 1-alpha*a, 1+alpha/a, -2*cosW0, 1-alpha/a)
+}
+
+// EQBand is one band of an equalizer (see ApplyEQ). Frequencies around FreqHz are boosted (GainDb>0) or cut (GainDb<0)
+// by GainDb decibels, and higher Q values make the band narrower. A Q of about 1.4 gives one octave wide bands
+type EQBand struct {
+	FreqHz float64
+	Q      float64
+	GainDb float64
+}
+
+// ApplyLowPass removes frequencies above cutoffHz from an in-memory sound. q controls the sharpness
+// around the cutoff, and 0.7071 gives a flat response with no peak.
+//
+// Like SetStereoWidth, the filter is applied to a copy of the data, so other sounds sharing the data are not affected.
+// Returns ErrSoundClosed if the sound is closed, or any error from replacing the player.
+//
+// Panics if the sound is not in-memory, if cutoffHz is not between 0 and half the sampling rate (both exclusive), or if q<=0
+func ApplyLowPass(s *Sound, cutoffHz, q float64) error {
+	validateFilterArgs(s, cutoffHz, q)
+	return s.applyBiquads(newLowPassBiquad(cutoffHz, q))
+}
+
+// ApplyHighPass removes frequencies below cutoffHz from an in-memory sound. See ApplyLowPass for details
+func ApplyHighPass(s *Sound, cutoffHz, q float64) error {
+	validateFilterArgs(s, cutoffHz, q)
+	return s.applyBiquads(newHighPassBiquad(cutoffHz, q))
+}
+
+// ApplyBandPass keeps the frequencies around centerHz and removes the rest, which is useful for effects like a telephone voice.
+// Higher q values give a narrower band. See ApplyLowPass for details
+func ApplyBandPass(s *Sound, centerHz, q float64) error {
+	validateFilterArgs(s, centerHz, q)
+	return s.applyBiquads(newBandPassBiquad(centerHz, q))
+}
+
+// ApplyNotch removes a narrow band of frequencies around centerHz, which is useful for removing things like mains hum at 50/60 Hz.
+// Higher q values give a narrower notch. See ApplyLowPass for details
+func ApplyNotch(s *Sound, centerHz, q float64) error {
+	validateFilterArgs(s, centerHz, q)
+	return s.applyBiquads(newNotchBiquad(centerHz, q))
+}
+
+// ApplyEQ applies an equalizer to an in-memory sound, where each band is a peaking filter and the bands are applied one after the other.
+// For example a simple three band EQ could use bands at 100 Hz, 1000 Hz and 10000 Hz with a Q of 0.7.
+//
+// Big boosts can make loud parts go out of range, in which case they are clipped. Cutting other bands or using ApplyGain avoids that.
+// See ApplyLowPass for more details.
+//
+// Returns ErrSoundClosed if the sound is closed, or any error from replacing the player. Applying no bands does nothing and returns nil.
+//
+// Panics if the sound is not in-memory, if band frequencies are not in ascending order, if any frequency is not between 0 and
+// half the sampling rate (both exclusive), or if any q<=0
+func ApplyEQ(s *Sound, bands []EQBand) error {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be filtered")
+	}
+
+	filters := eqBiquads(bands)
+	if len(filters) == 0 {
+		return nil
+	}
+
+	return s.applyBiquads(filters...)
+}
+
+// eqBiquads returns the peaking filters of the bands, and panics like ApplyEQ if the bands are invalid
+func eqBiquads(bands []EQBand) []biquad {
+
+	filters := make([]biquad, len(bands))
+	for i, band := range bands {
+
+		validateFilterParams(band.FreqHz, band.Q)
+		if i > 0 && band.FreqHz <= bands[i-1].FreqHz {
+			panic("eq band frequencies must be in ascending order")
+		}
+
+		filters[i] = newPeakingBiquad(band.FreqHz, band.Q, band.GainDb)
+	}
+
+	return filters
+}
+
+func validateFilterArgs(s *Sound, freqHz, q float64) {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be filtered")
+	}
+
+	validateFilterParams(freqHz, q)
+}
+
+func validateFilterParams(freqHz, q float64) {
+
+	if freqHz <= 0 || freqHz >= float64(SamplingRate)/2 {
+		panic("filter frequency must be bigger than zero and less than half the sampling rate")
+	}
+
+	if q <= 0 {
+		panic("filter q must be bigger than zero")
+	}
+}
+
+// applyBiquads runs the filters in order over a copy of the sound's data, then replaces the data with the result.
+// Returns ErrSoundClosed if the sound is closed, or any error from replacing the player
+func (s *Sound) applyBiquads(filters ...biquad) error {
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	sb := s.Data.(*SoundBuffer)
+	newSb := &SoundBuffer{
+		Data: filterPCM16(sb.Data, int(ChanCount), filters),
+		Pos:  s.currBytePos(),
+	}
+
+	return s.replaceData(newSb)
+}
+
+// filterPCM16 returns a copy of the interleaved PCM16 data after running the filters in order on every channel.
+// Samples that go out of range are saturated
+func filterPCM16(pcm []byte, chanCount int, filters []biquad) []byte {
+
+	out := make([]byte, len(pcm))
+	copy(out, pcm)
+
+	if chanCount <= 0 {
+		return out
+	}
+
+	// One state per channel per filter
+	states := make([]biquadState, chanCount*len(filters))
+	for i := 0; i+1 < len(out); i += 2 {
+
+		ch := (i / 2) % chanCount
+		x := float64(getPCM16Sample(out, i))
+		for f := range filters {
+			x = filters[f].process(&states[ch*len(filters)+f], x)
+		}
+
+		putPCM16Sample(out, i, saturateToI16(x))
+	}
+
+	return out
+}
+
+// LowPassEffect is the Effect version of ApplyLowPass, and panics on use if its fields are invalid like ApplyLowPass
+type LowPassEffect struct {
+	CutoffHz float64
+	Q        float64
+}
+
+func (e LowPassEffect) Process(pcm []byte) []byte {
+	validateFilterParams(e.CutoffHz, e.Q)
+	return filterPCM16(pcm, int(ChanCount), []biquad{newLowPassBiquad(e.CutoffHz, e.Q)})
+}
+
+// HighPassEffect is the Effect version of ApplyHighPass, and panics on use if its fields are invalid like ApplyHighPass
+type HighPassEffect struct {
+	CutoffHz float64
+	Q        float64
+}
+
+func (e HighPassEffect) Process(pcm []byte) []byte {
+	validateFilterParams(e.CutoffHz, e.Q)
+	return filterPCM16(pcm, int(ChanCount), []biquad{newHighPassBiquad(e.CutoffHz, e.Q)})
+}
+
+// BandPassEffect is the Effect version of ApplyBandPass, and panics on use if its fields are invalid like ApplyBandPass
+type BandPassEffect struct {
+	CenterHz float64
+	Q        float64
+}
+
+func (e BandPassEffect) Process(pcm []byte) []byte {
+	validateFilterParams(e.CenterHz, e.Q)
+	return filterPCM16(pcm, int(ChanCount), []biquad{newBandPassBiquad(e.CenterHz, e.Q)})
+}
+
+// NotchEffect is the Effect version of ApplyNotch, and panics on use if its fields are invalid like ApplyNotch
+type NotchEffect struct {
+	CenterHz float64
+	Q        float64
+}
+
+func (e NotchEffect) Process(pcm []byte) []byte {
+	validateFilterParams(e.CenterHz, e.Q)
+	return filterPCM16(pcm, int(ChanCount), []biquad{newNotchBiquad(e.CenterHz, e.Q)})
+}
+
+// EQEffect is the Effect version of ApplyEQ, and panics on use if its bands are invalid like ApplyEQ
+type EQEffect struct {
+	Bands []EQBand
+}
+
+func (e EQEffect) Process(pcm []byte) []byte {
+
+	filters := eqBiquads(e.Bands)
+	if len(filters) == 0 {
+		return pcm
+	}
+
+	return filterPCM16(pcm, int(ChanCount), filters)
+}
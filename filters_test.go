@@ -0,0 +1,81 @@
+package wavy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFilters(t *testing.T) {
+
+	oldSamplingRate := SamplingRate
+	SamplingRate = SampleRate_44100
+	defer func() { SamplingRate = oldSamplingRate }()
+
+	// One second of a stereo 1 kHz sine
+	const freq = 1000
+	pcm := make([]byte, 44100*4)
+	for i := 0; i < 44100; i++ {
+		x := int16(math.Sin(2*math.Pi*freq*float64(i)/44100) * 16000)
+		putPCM16Sample(pcm, i*4, x)
+		putPCM16Sample(pcm, i*4+2, x)
+	}
+
+	inRMS := pcm16RMS(pcm)
+	tests := []struct {
+		name     string
+		filter   biquad
+		minRatio float64
+		maxRatio float64
+	}{
+		{name: "BandPassCenter", filter: newBandPassBiquad(freq, 2), minRatio: 0.9, maxRatio: 1.1},
+		{name: "BandPassOff", filter: newBandPassBiquad(8000, 2), minRatio: 0, maxRatio: 0.2},
+		{name: "NotchCenter", filter: newNotchBiquad(freq, 2), minRatio: 0, maxRatio: 0.1},
+		{name: "NotchOff", filter: newNotchBiquad(8000, 2), minRatio: 0.9, maxRatio: 1.1},
+		{name: "LowPass", filter: newLowPassBiquad(200, 0.7071), minRatio: 0, maxRatio: 0.1},
+		{name: "HighPass", filter: newHighPassBiquad(200, 0.7071), minRatio: 0.9, maxRatio: 1.1},
+		{name: "PeakingBoost", filter: newPeakingBiquad(freq, 1.4, 6), minRatio: 1.9, maxRatio: 2.1},
+		{name: "PeakingCut", filter: newPeakingBiquad(freq, 1.4, -6), minRatio: 0.45, maxRatio: 0.55},
+		{name: "PeakingOff", filter: newPeakingBiquad(8000, 1.4, 6), minRatio: 0.9, maxRatio: 1.1},
+	}
+
+	for _, test := range tests {
+
+		// Skip the first 100ms so the filter settles
+		out := filterPCM16(pcm, 2, []biquad{test.filter})
+		ratio := pcm16RMS(out[4410*4:]) / inRMS
+		if ratio < test.minRatio || ratio > test.maxRatio {
+			t.Errorf("%s: expected output/input RMS ratio to be within [%f, %f] but got %f\n", test.name, test.minRatio, test.maxRatio, ratio)
+		}
+	}
+}
+
+func pcm16RMS(pcm []byte) float64 {
+
+	sum := 0.0
+	for i := 0; i+1 < len(pcm); i += 2 {
+		x := float64(getPCM16Sample(pcm, i))
+		sum += x * x
+	}
+
+	return math.Sqrt(sum / float64(len(pcm)/2))
+}
+
+func TestFiltersClosed(t *testing.T) {
+
+	s, _, _ := newFakeSound(t, time.Second)
+	oldSamplingRate := SamplingRate
+	defer func() { SamplingRate = oldSamplingRate }()
+	SamplingRate = SampleRate_44100
+
+	s.Data = nil
+	if err := ApplyLowPass(s, 1000, 0.7071); err != ErrSoundClosed {
+		t.Errorf("Expected ErrSoundClosed when filtering a closed sound but got '%v'\n", err)
+		return
+	}
+
+	if err := ApplyEQ(s, []EQBand{{FreqHz: 1000, Q: 0.7, GainDb: 3}}); err != ErrSoundClosed {
+		t.Errorf("Expected ErrSoundClosed when applying EQ to a closed sound but got '%v'\n", err)
+		return
+	}
+}
@@ -0,0 +1,57 @@
+package wavy
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// testToneHz is the frequency of the sine wave in generated test sounds
+	testToneHz = 440
+)
+
+// GenerateTestWav returns a 16-bit PCM WAV file of a 440 Hz sine wave that is 'd' long, in the format of the context
+// or in 44100 Hz stereo if wavy isn't initialized. It's meant for tests that need sound files without shipping binary assets,
+// and the result can be written to a file and loaded like any other WAV.
+//
+// There are no encoders for OGG or MP3 in wavy's dependencies, so only WAV can be generated
+func GenerateTestWav(d time.Duration) []byte {
+
+	if SamplingRate == 0 || ChanCount == 0 {
+		return GenerateTestWavFmt(d, SampleRate_44100, SoundChannelCount_2)
+	}
+
+	return GenerateTestWavFmt(d, SamplingRate, ChanCount)
+}
+
+// GenerateTestWavFmt is like GenerateTestWav but with a specific sample rate and channel count,
+// which is useful for things like testing sample rate and channel conversions.
+//
+// Panics if rate or ch are not bigger than zero
+func GenerateTestWavFmt(d time.Duration, rate SampleRate, ch SoundChannelCount) []byte {
+
+	if rate <= 0 || ch <= 0 {
+		panic("test wav sample rate and channel count must be bigger than zero")
+	}
+
+	if d < 0 {
+		d = 0
+	}
+
+	frames := int64(d) * int64(rate) / int64(time.Second)
+	frameSize := int64(ch) * int64(SoundBitDepth_2)
+
+	wav := make([]byte, wavHeaderSize+frames*frameSize)
+	copy(wav, makeWavHeader(frames*frameSize, rate, ch, SoundBitDepth_2))
+
+	pcm := wav[wavHeaderSize:]
+	for i := int64(0); i < frames; i++ {
+
+		x := int16(math.Sin(2*math.Pi*testToneHz*float64(i)/float64(rate)) * math.MaxInt16 / 2)
+		for c := int64(0); c < int64(ch); c++ {
+			putPCM16Sample(pcm, int(i*frameSize+c*2), x)
+		}
+	}
+
+	return wav
+}
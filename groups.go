@@ -0,0 +1,202 @@
+package wavy
+
+import "sync"
+
+// groups maps group names to the sounds in them, and groupEffects maps group names to their effect (see SetGroupEffect)
+var (
+	groupsLock   sync.Mutex
+	groups       = map[string]map[*Sound]struct{}{}
+	groupEffects = map[string]Effect{}
+)
+
+// SetGroup moves the sound into the named group (also called a bus), for example "music", "sfx" or "ui",
+// so it can be controlled together with the other sounds in it using PauseGroup, ResumeGroup, SetGroupVolume and SetGroupEffect.
+// A sound is in at most one group, and an empty name removes it from its group. Closing a sound also removes it from its group.
+//
+// Moving an in-memory sound into a group with an effect applies it, and moving it out of one gives it back its data from before the effect.
+// If that fails the sound is still moved, but keeps playing the data it had, and the error from replacing the player is returned
+func (s *Sound) SetGroup(name string) error {
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+
+	return s.setGroup(name, true)
+}
+
+// setGroup implements SetGroup, and must be called with groupsLock held.
+// If updateEffect is false the group effect is left as is, which is used when closing since the data is going away anyway
+func (s *Sound) setGroup(name string, updateEffect bool) error {
+
+	if s.group != "" {
+
+		delete(groups[s.group], s)
+		if len(groups[s.group]) == 0 {
+			delete(groups, s.group)
+		}
+	}
+
+	s.group = name
+	s.groupPaused = false
+
+	var err error
+	if updateEffect {
+		err = s.applyGroupEffect(groupEffects[name])
+	}
+
+	if name == "" {
+		return err
+	}
+
+	if groups[name] == nil {
+		groups[name] = map[*Sound]struct{}{}
+	}
+	groups[name][s] = struct{}{}
+
+	return err
+}
+
+// Group returns the name of the group the sound is in, or an empty string if it's not in one
+func (s *Sound) Group() string {
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+
+	return s.group
+}
+
+// PauseGroup pauses all the playing sounds in the group. Unlike PauseAllSounds this pauses the sounds themselves,
+// so as with Pause any running loops end. Sounds that weren't playing are not affected
+func PauseGroup(name string) {
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+
+	for s := range groups[name] {
+
+		if !s.IsPlaying() {
+			continue
+		}
+
+		s.Pause()
+		s.groupPaused = true
+	}
+}
+
+// ResumeGroup plays the sounds of the group that were paused by PauseGroup, continuing from where they were paused
+func ResumeGroup(name string) {
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+
+	for s := range groups[name] {
+
+		if !s.groupPaused {
+			continue
+		}
+
+		s.groupPaused = false
+		s.PlayAsync()
+	}
+}
+
+// SetGroupVolume sets the volume of all the sounds in the group, like calling SetVolume on each of them.
+// Sounds added to the group later keep their own volume.
+//
+// Volume must be between 0 and 1 (both inclusive), otherwise this panics like SetVolume
+func SetGroupVolume(name string, volume float64) {
+
+	if volume < 0 || volume > 1 {
+		panic("sound volume can not be less than zero or bigger than one")
+	}
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+
+	for s := range groups[name] {
+		s.SetVolume(volume)
+	}
+}
+
+// SetGroupEffect sets an effect that is applied to all the sounds of the group, like a low-pass on an "underwater" bus.
+// Sounds added to the group later get the effect too, and a nil effect removes it.
+//
+// wavy plays each sound through its own player so there is no shared mix to process. Instead, the effect processes a copy of
+// the data of each in-memory member, which then plays the processed data while its original data is kept so that changing or removing
+// the effect (or leaving the group) restores it. Playback continues from the same position. This means that setting an effect costs time
+// and memory proportional to the size of the members, and that data effects applied to a member while it has a group effect (e.g. ApplyLowPass)
+// are lost when the group effect is changed or removed. Since both the original and processed data are kept, TotalInMemoryBytes counts both
+// while the effect is set.
+//
+// Streaming members are not affected, since their data isn't available before it's played.
+//
+// A member whose player can't be replaced keeps playing the data it had, and the errors of all such members are returned as a MultiError
+func SetGroupEffect(name string, effect Effect) error {
+
+	groupsLock.Lock()
+	defer groupsLock.Unlock()
+
+	if effect == nil {
+		delete(groupEffects, name)
+	} else {
+		groupEffects[name] = effect
+	}
+
+	var errs MultiError
+	for s := range groups[name] {
+		if err := s.applyGroupEffect(effect); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// applyGroupEffect makes an in-memory sound play its original data processed by effect, or play its original data if effect is nil.
+// Must be called with groupsLock held. If replacing the player fails then the sound keeps its data and the error is returned
+func (s *Sound) applyGroupEffect(effect Effect) error {
+
+	if s.Info.Mode != SoundMode_Memory || s.IsClosed() {
+		return nil
+	}
+
+	if effect == nil && s.dryData == nil {
+		return nil
+	}
+
+	// Replacing the data uncounts it, so we keep the dry data counted ourselves while it's held
+	firstEffect := s.dryData == nil
+	if firstEffect {
+		s.dryData = s.Data.(*SoundBuffer)
+		holdDryMem(s)
+	}
+
+	newSb := s.dryData
+	if effect != nil {
+		pcm := make([]byte, len(s.dryData.Data))
+		copy(pcm, s.dryData.Data)
+		newSb = &SoundBuffer{Data: effect.Process(pcm)}
+	}
+
+	newSb.Pos = clampByteCount(s.currBytePos(), newSb.Len())
+	if err := s.replaceData(newSb); err != nil {
+
+		if firstEffect {
+			s.dryData = nil
+			dropDryMem(s)
+		}
+
+		return err
+	}
+
+	if effect == nil {
+		s.dryData = nil
+		restoreDryMem(s)
+	}
+
+	s.Info.Size = newSb.Len()
+	return nil
+}
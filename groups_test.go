@@ -0,0 +1,56 @@
+package wavy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroups(t *testing.T) {
+
+	s1, fp1, _ := newFakeSound(t, time.Second)
+	s2, _, _ := newFakeSound(t, time.Second)
+	notInGroup, fp3, _ := newFakeSound(t, time.Second)
+	defer s1.Pause()
+
+	// The finish watcher started by ResumeGroup moves the fake clock fast, so the sounds must play long enough to only stop when paused
+	fp1.playDuration = 1 << 62
+	fp3.playDuration = 1 << 62
+
+	s1.SetGroup("sfx")
+	s2.SetGroup("sfx")
+	s1.Player.Play()
+	notInGroup.Player.Play()
+
+	PauseGroup("sfx")
+	if s1.IsPlaying() || s2.IsPlaying() || !notInGroup.IsPlaying() {
+		t.Errorf("Expected PauseGroup to only pause the playing sounds of the group\n")
+		return
+	}
+
+	// Only sounds paused by PauseGroup are resumed
+	ResumeGroup("sfx")
+	if !s1.IsPlaying() || s2.IsPlaying() {
+		t.Errorf("Expected ResumeGroup to only play the sounds paused by PauseGroup\n")
+		return
+	}
+
+	notInGroup.SetVolume(1)
+	SetGroupVolume("sfx", 0.3)
+	if s1.Volume() != 0.3 || s2.Volume() != 0.3 || notInGroup.Volume() != 1 {
+		t.Errorf("Expected SetGroupVolume to only change the volume of the group's sounds\n")
+		return
+	}
+
+	// Closing removes the sound from its group
+	s2.Close()
+	if s2.Group() != "" || len(groups["sfx"]) != 1 {
+		t.Errorf("Expected closing a sound to remove it from its group\n")
+		return
+	}
+
+	s1.SetGroup("")
+	if _, ok := groups["sfx"]; ok {
+		t.Errorf("Expected empty groups to be removed\n")
+		return
+	}
+}
@@ -0,0 +1,75 @@
+package wavy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ error = MultiError{}
+
+// MultiError holds all the errors that happened during an operation that doesn't stop on the first error (e.g. LoadDir)
+type MultiError []error
+
+func (me MultiError) Error() string {
+
+	errStrings := make([]string, len(me))
+	for i := 0; i < len(me); i++ {
+		errStrings[i] = me[i].Error()
+	}
+
+	return strings.Join(errStrings, "; ")
+}
+
+// LoadDir loads every sound file with a supported type in 'dir' using the given mode, and returns them keyed by the
+// file name without the extension (e.g. 'sfx/shot.mp3' is keyed as 'shot'). Sub-directories and files of unknown or unsupported types are skipped.
+//
+// A file that fails to load doesn't stop the loading of the rest. The returned map has all the sounds that loaded successfully,
+// and the errors of the ones that didn't are returned as a MultiError.
+// If two files have the same name but different extensions (e.g. 'shot.mp3' and 'shot.wav') only the first is loaded and an error is recorded for the other
+func LoadDir(dir string, mode SoundMode) (map[string]*Sound, error) {
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs MultiError
+	sounds := make(map[string]*Sound, len(entries))
+	for _, entry := range entries {
+
+		if entry.IsDir() || !IsSoundTypeSupported(GetSoundFileType(entry.Name())) {
+			continue
+		}
+
+		fpath := filepath.Join(dir, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, ok := sounds[name]; ok {
+			errs = append(errs, fmt.Errorf("skipped '%s' because another sound is already loaded with the name '%s'", fpath, name))
+			continue
+		}
+
+		var s *Sound
+		if mode == SoundMode_Streaming {
+			s, err = NewSoundStreaming(fpath)
+		} else {
+			s, err = NewSoundMem(fpath)
+		}
+
+		// Partially decoded sounds come with an error but are still usable
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if s != nil {
+			sounds[name] = s
+		}
+	}
+
+	if len(errs) > 0 {
+		return sounds, errs
+	}
+
+	return sounds, nil
+}
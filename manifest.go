@@ -0,0 +1,135 @@
+package wavy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+var ErrInvalidManifest = errors.New("invalid sound manifest")
+
+// manifest is the JSON layout read by LoadFromManifest
+type manifest struct {
+	Sounds map[string]manifestSound `json:"sounds"`
+}
+
+type manifestSound struct {
+	Path      string `json:"path"`
+	Streaming bool   `json:"streaming"`
+
+	// Pointers are used so that missing values leave the sound's defaults as they are
+	Volume      *float64 `json:"volume"`
+	StereoWidth *float64 `json:"stereoWidth"`
+	GainDb      *float64 `json:"gainDb"`
+}
+
+// LoadFromManifest loads the sounds defined in a JSON manifest and applies their settings, returning them keyed by their name in the manifest.
+// This allows games and apps to define their sounds as data. An example manifest:
+//
+//	{
+//		"sounds": {
+//			"click": { "path": "sfx/click.wav", "volume": 0.5 },
+//			"shot":  { "path": "sfx/shot.ogg", "gainDb": -3, "stereoWidth": 1.5 },
+//			"music": { "path": "music/theme.ogg", "streaming": true, "volume": 0.8 }
+//		}
+//	}
+//
+// Sounds are loaded into memory unless 'streaming' is true. Relative paths are relative to the working directory.
+// 'volume' is between 0 and 1, while 'stereoWidth' and 'gainDb' change the data (see SetStereoWidth and ApplyGain) so they are only allowed for in-memory sounds.
+// Unknown fields are an error, so typos don't go unnoticed.
+//
+// Like LoadDir, a sound that fails to load doesn't stop the loading of the rest. The returned map has all the sounds that loaded successfully,
+// and the errors of the ones that didn't are returned as a MultiError. If the manifest itself can't be parsed then no sounds are loaded
+func LoadFromManifest(r io.Reader) (map[string]*Sound, error) {
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var m manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidManifest, err)
+	}
+
+	// Sorting makes loading and error order stable
+	names := make([]string, 0, len(m.Sounds))
+	for name := range m.Sounds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs MultiError
+	sounds := make(map[string]*Sound, len(m.Sounds))
+	for _, name := range names {
+
+		s, err := loadManifestSound(m.Sounds[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sound '%s': %w", name, err))
+			continue
+		}
+
+		sounds[name] = s
+	}
+
+	if len(errs) > 0 {
+		return sounds, errs
+	}
+
+	return sounds, nil
+}
+
+// loadManifestSound validates the definition before loading, so invalid settings don't panic after the sound is loaded
+func loadManifestSound(def manifestSound) (*Sound, error) {
+
+	if def.Path == "" {
+		return nil, fmt.Errorf("%w: path is required", ErrInvalidManifest)
+	}
+
+	if def.Volume != nil && (*def.Volume < 0 || *def.Volume > 1) {
+		return nil, fmt.Errorf("%w: volume must be between 0 and 1 but is %f", ErrInvalidManifest, *def.Volume)
+	}
+
+	if def.Streaming && (def.StereoWidth != nil || def.GainDb != nil) {
+		return nil, fmt.Errorf("%w: stereoWidth and gainDb are only allowed for in-memory sounds", ErrInvalidManifest)
+	}
+
+	var s *Sound
+	var err error
+	if def.Streaming {
+		s, err = NewSoundStreaming(def.Path)
+	} else {
+		s, err = NewSoundMem(def.Path)
+	}
+
+	// Partially decoded sounds are returned with an error, but manifests should only give complete sounds
+	if err != nil {
+
+		if s != nil {
+			s.Close()
+		}
+
+		return nil, err
+	}
+
+	if def.GainDb != nil {
+		if err := ApplyGain(s, math.Pow(10, *def.GainDb/20)); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if def.StereoWidth != nil {
+		if err := s.SetStereoWidth(*def.StereoWidth); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	if def.Volume != nil {
+		s.SetVolume(*def.Volume)
+	}
+
+	return s, nil
+}
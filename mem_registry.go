@@ -0,0 +1,154 @@
+package wavy
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// memBlock is decoded audio held by in-memory sounds. Sounds that share data share the same block, so it's only counted once,
+// and it's uncounted when the last of them is closed or garbage collected
+type memBlock struct {
+	size int64
+	refs int
+}
+
+// memBlocksLock guards the mem field of sounds and the refs of blocks. totalMemBytes is the sum of the sizes of live blocks.
+//
+// Sounds are intentionally not stored anywhere here, so that an in-memory sound that is never closed can still be garbage collected
+var (
+	memBlocksLock sync.Mutex
+	totalMemBytes int64
+)
+
+// TotalInMemoryBytes returns the number of bytes of decoded audio held by in-memory sounds that are not yet closed.
+// Sounds that share data (e.g. made with CopyInMemSound or ClipInMemSoundPercent) only count the shared data once.
+//
+// This can be used to budget memory, for example by loading more sounds as streaming once the total gets too big
+func TotalInMemoryBytes() int64 {
+	return atomic.LoadInt64(&totalMemBytes)
+}
+
+// registerMemSound counts the data of a newly loaded in-memory sound, and makes sure it's uncounted if the sound is never closed
+func registerMemSound(s *Sound) {
+
+	memBlocksLock.Lock()
+	s.mem = newMemBlock(s.Data.(*SoundBuffer).Data)
+	memBlocksLock.Unlock()
+
+	runtime.SetFinalizer(s, unregisterMemSound)
+}
+
+// registerMemSoundCopy is like registerMemSound, but if newSound uses the same data as 'from' then it shares its block instead of adding to the total
+func registerMemSoundCopy(newSound, from *Sound) {
+
+	memBlocksLock.Lock()
+	if from.mem != nil && sameArray(newSound.Data.(*SoundBuffer).Data, from.Data.(*SoundBuffer).Data) {
+		from.mem.refs++
+		newSound.mem = from.mem
+	} else {
+		newSound.mem = newMemBlock(newSound.Data.(*SoundBuffer).Data)
+	}
+	memBlocksLock.Unlock()
+
+	runtime.SetFinalizer(newSound, unregisterMemSound)
+}
+
+// updateMemSound recounts the data of an in-memory sound whose data is changing from oldData to newData
+func updateMemSound(s *Sound, oldData, newData []byte) {
+
+	memBlocksLock.Lock()
+	defer memBlocksLock.Unlock()
+
+	if s.mem == nil || sameArray(oldData, newData) {
+		return
+	}
+
+	releaseMemBlock(s.mem)
+	s.mem = newMemBlock(newData)
+}
+
+// holdDryMem keeps the current data of s counted after it's replaced, which is used while it's kept as the dry data of a group effect
+func holdDryMem(s *Sound) {
+
+	memBlocksLock.Lock()
+	defer memBlocksLock.Unlock()
+
+	if s.mem == nil || s.dryMem != nil {
+		return
+	}
+
+	s.mem.refs++
+	s.dryMem = s.mem
+}
+
+// restoreDryMem counts the data of s using the block kept by holdDryMem, and must be called once the data of s is back to the dry data
+func restoreDryMem(s *Sound) {
+
+	memBlocksLock.Lock()
+	defer memBlocksLock.Unlock()
+
+	if s.dryMem == nil {
+		return
+	}
+
+	if s.mem != nil {
+		releaseMemBlock(s.mem)
+	}
+
+	s.mem = s.dryMem
+	s.dryMem = nil
+}
+
+// dropDryMem releases the block kept by holdDryMem, which is used when the dry data ends up not being kept
+func dropDryMem(s *Sound) {
+
+	memBlocksLock.Lock()
+	if s.dryMem != nil {
+		releaseMemBlock(s.dryMem)
+		s.dryMem = nil
+	}
+	memBlocksLock.Unlock()
+}
+
+// unregisterMemSound uncounts the data of s (including any dry data kept by holdDryMem) unless other sounds still share it.
+// Calling it more than once is fine
+func unregisterMemSound(s *Sound) {
+
+	memBlocksLock.Lock()
+	if s.mem != nil {
+		releaseMemBlock(s.mem)
+		s.mem = nil
+	}
+
+	if s.dryMem != nil {
+		releaseMemBlock(s.dryMem)
+		s.dryMem = nil
+	}
+	memBlocksLock.Unlock()
+}
+
+// newMemBlock must be called with memBlocksLock held
+func newMemBlock(data []byte) *memBlock {
+	atomic.AddInt64(&totalMemBytes, int64(len(data)))
+	return &memBlock{size: int64(len(data)), refs: 1}
+}
+
+// releaseMemBlock must be called with memBlocksLock held
+func releaseMemBlock(b *memBlock) {
+
+	b.refs--
+	if b.refs == 0 {
+		atomic.AddInt64(&totalMemBytes, -b.size)
+	}
+}
+
+// sameArray reports whether a and b are slices of the same array. Slices of the same array end their capacity at the same address
+func sameArray(a, b []byte) bool {
+
+	if cap(a) == 0 || cap(b) == 0 {
+		return false
+	}
+
+	return &a[:cap(a)][cap(a)-1] == &b[:cap(b)][cap(b)-1]
+}
@@ -0,0 +1,97 @@
+package wavy
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestMemRegistry(t *testing.T) {
+
+	before := TotalInMemoryBytes()
+	s := &Sound{Data: &SoundBuffer{Data: make([]byte, 1000)}, Info: SoundInfo{Mode: SoundMode_Memory}}
+	registerMemSound(s)
+	if TotalInMemoryBytes() != before+1000 {
+		t.Errorf("Expected total to be %d after registering but got %d\n", before+1000, TotalInMemoryBytes())
+		return
+	}
+
+	// A clip shares the data, but a converted copy doesn't
+	clip := &Sound{Data: &SoundBuffer{Data: s.Data.(*SoundBuffer).Data[100:200]}}
+	registerMemSoundCopy(clip, s)
+	converted := &Sound{Data: &SoundBuffer{Data: make([]byte, 500)}}
+	registerMemSoundCopy(converted, s)
+	if TotalInMemoryBytes() != before+1500 {
+		t.Errorf("Expected total to be %d after copying but got %d\n", before+1500, TotalInMemoryBytes())
+		return
+	}
+
+	unregisterMemSound(s)
+	unregisterMemSound(s)
+	if TotalInMemoryBytes() != before+1500 {
+		t.Errorf("Expected data still used by the clip to be counted, but got %d instead of %d\n", TotalInMemoryBytes(), before+1500)
+		return
+	}
+
+	updateMemSound(clip, clip.Data.(*SoundBuffer).Data, make([]byte, 10))
+	unregisterMemSound(converted)
+	if TotalInMemoryBytes() != before+10 {
+		t.Errorf("Expected total to be %d after replacing data and unregistering but got %d\n", before+10, TotalInMemoryBytes())
+		return
+	}
+
+	unregisterMemSound(clip)
+	if TotalInMemoryBytes() != before {
+		t.Errorf("Expected total to be %d after unregistering everything but got %d\n", before, TotalInMemoryBytes())
+		return
+	}
+}
+
+func TestMemRegistryDryData(t *testing.T) {
+
+	before := TotalInMemoryBytes()
+	dry := make([]byte, 1000)
+	s := &Sound{Data: &SoundBuffer{Data: dry}, Info: SoundInfo{Mode: SoundMode_Memory}}
+	registerMemSound(s)
+
+	// Dry data kept by a group effect stays counted along with the processed data
+	holdDryMem(s)
+	wet := make([]byte, 1000)
+	updateMemSound(s, dry, wet)
+	if TotalInMemoryBytes() != before+2000 {
+		t.Errorf("Expected dry and processed data to be counted as %d but got %d\n", before+2000, TotalInMemoryBytes())
+		return
+	}
+
+	updateMemSound(s, wet, dry)
+	restoreDryMem(s)
+	if TotalInMemoryBytes() != before+1000 {
+		t.Errorf("Expected only the dry data to be counted as %d after restoring it but got %d\n", before+1000, TotalInMemoryBytes())
+		return
+	}
+
+	holdDryMem(s)
+	updateMemSound(s, dry, wet)
+	unregisterMemSound(s)
+	if TotalInMemoryBytes() != before {
+		t.Errorf("Expected total to be %d after unregistering a sound with dry data but got %d\n", before, TotalInMemoryBytes())
+		return
+	}
+}
+
+func TestMemRegistryFinalizer(t *testing.T) {
+
+	before := TotalInMemoryBytes()
+	registerMemSound(&Sound{Data: &SoundBuffer{Data: make([]byte, 1000)}, Info: SoundInfo{Mode: SoundMode_Memory}})
+
+	// Sounds that are never closed are uncounted once they are garbage collected
+	for i := 0; i < 50 && TotalInMemoryBytes() != before; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	if TotalInMemoryBytes() != before {
+		t.Errorf("Expected garbage collected sound to be uncounted, but total is %d instead of %d\n", TotalInMemoryBytes(), before)
+		return
+	}
+}
@@ -0,0 +1,91 @@
+package wavy
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// rmsWindow is how much audio after the play head is used to compute RMS values
+	rmsWindow = 50 * time.Millisecond
+)
+
+// CurrentRMS returns the loudness of the audio at the play head as the root mean square of the samples
+// in the next 50ms, normalized to [0,1]. All channels are combined into one value.
+//
+// Only in-memory sounds can be measured, since for streaming sounds that would mean reading ahead of the player.
+// Streaming and closed sounds return zero
+func (s *Sound) CurrentRMS() float64 {
+
+	sumSquares, sampleCounts := s.sumSquaresNearPlayHead()
+
+	totalSum := 0.0
+	totalCount := 0
+	for i := 0; i < len(sumSquares); i++ {
+		totalSum += sumSquares[i]
+		totalCount += sampleCounts[i]
+	}
+
+	if totalCount == 0 {
+		return 0
+	}
+
+	return math.Sqrt(totalSum/float64(totalCount)) / -math.MinInt16
+}
+
+// CurrentChannelRMS is like CurrentRMS but measures the left and right channels separately, which is what stereo meters need.
+// If the context is mono then both values are the same
+func (s *Sound) CurrentChannelRMS() (left, right float64) {
+
+	sumSquares, sampleCounts := s.sumSquaresNearPlayHead()
+	if len(sumSquares) == 0 {
+		return 0, 0
+	}
+
+	rms := func(ch int) float64 {
+
+		if sampleCounts[ch] == 0 {
+			return 0
+		}
+
+		return math.Sqrt(sumSquares[ch]/float64(sampleCounts[ch])) / -math.MinInt16
+	}
+
+	left = rms(0)
+	if len(sumSquares) == 1 {
+		return left, left
+	}
+
+	return left, rms(1)
+}
+
+// sumSquaresNearPlayHead de-interleaves the samples within rmsWindow of the play head, and returns
+// the sum of squares and the number of samples for each channel.
+// Nil is returned for sounds that can't be measured
+func (s *Sound) sumSquaresNearPlayHead() (sumSquares []float64, sampleCounts []int) {
+
+	if s.IsClosed() || s.Info.Mode != SoundMode_Memory || ChanCount == 0 {
+		return nil, nil
+	}
+
+	data := s.Data.(*SoundBuffer).Data
+	start := alignToSample(s.currBytePos())
+	end := start + ByteCountFromPlayTime(rmsWindow)
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+
+	chanCount := int(ChanCount)
+	sumSquares = make([]float64, chanCount)
+	sampleCounts = make([]int, chanCount)
+	for i := start; i+1 < end; i += 2 {
+
+		ch := int((i-start)/2) % chanCount
+		x := float64(getPCM16Sample(data, int(i)))
+
+		sumSquares[ch] += x * x
+		sampleCounts[ch]++
+	}
+
+	return sumSquares, sampleCounts
+}
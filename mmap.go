@@ -0,0 +1,66 @@
+package wavy
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+var errMmapUnsupported = errors.New("memory mapping is not supported on this platform")
+
+// NewSoundMmap is like NewSoundStreaming but memory maps the file instead of reading it through file reads.
+// The sound still decodes on the fly so memory use stays low, but the OS pages the file in as needed,
+// which makes seeking (especially backwards) about as cheap as with in-memory data.
+// The mapping is released by Close.
+//
+// On platforms without memory mapping support this falls back to NewSoundStreaming.
+// Loading failures are returned as a *LoadError
+func NewSoundMmap(fpath string) (*Sound, error) {
+
+	soundType := GetSoundFileType(fpath)
+	if soundType == SoundType_Unknown {
+		return nil, newLoadError(fpath, soundType, errUnknownSoundType)
+	}
+
+	if !IsSoundTypeSupported(soundType) {
+		return nil, newLoadError(fpath, soundType, ErrUnsupportedSoundType)
+	}
+
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, newLoadError(fpath, soundType, err)
+	}
+
+	// The mapping stays valid after the file is closed, so we don't need to keep it open
+	data, unmap, err := mmapFile(file)
+	file.Close()
+	if err == errMmapUnsupported {
+		return NewSoundStreaming(fpath)
+	}
+
+	if err != nil {
+		return nil, newLoadError(fpath, soundType, err)
+	}
+
+	s := &Sound{
+		fpath: fpath,
+		unmap: unmap,
+		Info: SoundInfo{
+			Type: soundType,
+			Mode: SoundMode_Streaming,
+		},
+	}
+
+	err = soundFromReadSeeker(bytes.NewReader(data), s)
+	if err != nil {
+		unmap()
+		return nil, newLoadError(fpath, soundType, err)
+	}
+
+	if err := checkSampleRate(s); err != nil {
+		s.Close()
+		return nil, newLoadError(fpath, soundType, err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package wavy
+
+import "os"
+
+func mmapFile(f *os.File) (data []byte, unmap func() error, err error) {
+	return nil, nil, errMmapUnsupported
+}
@@ -0,0 +1,34 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package wavy
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the entire file as read-only memory, and returns the mapped bytes and a function that unmaps them
+func mmapFile(f *os.File) (data []byte, unmap func() error, err error) {
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	size := info.Size()
+	if size <= 0 {
+		return nil, nil, errors.New("can not memory map an empty file")
+	}
+
+	if int64(int(size)) != size {
+		return nil, nil, errors.New("file is too large to be memory mapped")
+	}
+
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
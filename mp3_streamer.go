@@ -0,0 +1,97 @@
+package wavy
+
+import (
+	"io"
+	"os"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+var _ io.ReadSeeker = &Mp3Streamer{}
+
+type Mp3Streamer struct {
+	readCounter
+
+	F   *os.File
+	Dec *mp3.Decoder
+
+	eofFlag
+
+	// resampler is set when the file sample rate is different from the context's, in which case reads go through it
+	// and all positions and sizes are in resampled bytes
+	resampler *pcm16Resampler
+
+	readErrReporter
+}
+
+// Read decodes into outBuf. If the file sample rate is different from the context's then the audio is resampled
+// to the context rate using linear interpolation
+func (ms *Mp3Streamer) Read(outBuf []byte) (bytesRead int, err error) {
+
+	outBuf = limitToStreamReadBufSize(outBuf)
+	if ms.resampler != nil {
+		bytesRead, err = ms.resampler.Read(outBuf)
+	} else {
+		bytesRead, err = ms.Dec.Read(outBuf)
+	}
+
+	ms.countRead(bytesRead)
+	ms.trackEOF(err)
+	ms.reportReadErr(err)
+
+	return bytesRead, err
+}
+
+func (ms *Mp3Streamer) Seek(offset int64, whence int) (int64, error) {
+
+	// The decoder might have more data after the new position, so any EOF no longer applies
+	ms.clearEOF()
+	if ms.resampler != nil {
+		return ms.resampler.Seek(offset, whence)
+	}
+
+	return ms.Dec.Seek(offset, whence)
+}
+
+// Size returns number of bytes, at the context sample rate
+func (ms *Mp3Streamer) Size() int64 {
+
+	if ms.resampler != nil {
+		return ms.resampler.Size()
+	}
+
+	return ms.Dec.Length()
+}
+
+// CanSeekBackCheaply returns true because go-mp3 keeps an index of frame positions, so a seek only decodes part of one frame
+func (ms *Mp3Streamer) CanSeekBackCheaply() bool {
+	return true
+}
+
+// NewMp3Streamer creates a streamer over mp3Dec. If wavy is initialized and the file sample rate is different from
+// the context's then reads are resampled to the context rate
+func NewMp3Streamer(f *os.File, mp3Dec *mp3.Decoder) *Mp3Streamer {
+	return &Mp3Streamer{
+		F:               f,
+		Dec:             mp3Dec,
+		resampler:       newMp3Resampler(mp3Dec),
+		readErrReporter: readErrReporter{errChan: make(chan error, readErrChanSize)},
+	}
+}
+
+// newMp3Resampler returns a resampler from the sample rate of dec to the context's, or nil if wavy isn't initialized or the rates match.
+// go-mp3 always decodes to stereo 16-bit PCM
+func newMp3Resampler(dec *mp3.Decoder) *pcm16Resampler {
+
+	if SamplingRate == 0 || dec.SampleRate() <= 0 || SampleRate(dec.SampleRate()) == SamplingRate {
+		return nil
+	}
+
+	const mp3FrameSize = 4
+	seekSrc := func(frame int64) error {
+		_, err := dec.Seek(frame*mp3FrameSize, io.SeekStart)
+		return err
+	}
+
+	return newPCM16Resampler(2, int64(dec.SampleRate()), int64(SamplingRate), dec.Length()/mp3FrameSize, dec.Read, seekSrc)
+}
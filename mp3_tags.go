@@ -0,0 +1,513 @@
+package wavy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	ErrNotMp3         = errors.New("sound is not an MP3")
+	ErrNoSoundSource  = errors.New("sound has no source to read from")
+	errBadID3v2Header = errors.New("invalid ID3v2 header")
+)
+
+const (
+	id3v2HeaderSize = 10
+	id3v1TagSize    = 128
+)
+
+// Mp3Tags holds the commonly used fields of ID3v1/ID3v2 tags.
+// Fields not present in the file are left empty
+type Mp3Tags struct {
+	Title   string
+	Artist  string
+	Album   string
+	Year    string
+	Track   string
+	Comment string
+
+	// Genre is the genre text in ID3v2 tags, but in ID3v1 tags it is the numeric genre ID (e.g. '17')
+	Genre string
+
+	// Artwork is the data of the attached picture, preferring the front cover if there are many.
+	// ArtworkMIME is its type (e.g. 'image/jpeg')
+	Artwork     []byte
+	ArtworkMIME string
+}
+
+// Mp3Tags reads the ID3 tags of the file the sound was loaded from.
+// If both ID3v2 and ID3v1 tags exist then ID3v2 values are used, and ID3v1 only fills missing fields.
+//
+// ErrNotMp3 is returned for non-MP3 sounds
+func (s *Sound) Mp3Tags() (Mp3Tags, error) {
+
+	if s.Info.Type != SoundType_MP3 {
+		return Mp3Tags{}, ErrNotMp3
+	}
+
+	// ReadAt doesn't move the file offset, so this is safe to do on a file that's being streamed
+	if s.File != nil {
+
+		stat, err := s.File.Stat()
+		if err != nil {
+			return Mp3Tags{}, err
+		}
+
+		return ReadMp3Tags(s.File, stat.Size())
+	}
+
+	if s.fpath == "" {
+		return Mp3Tags{}, ErrNoSoundSource
+	}
+
+	f, err := os.Open(s.fpath)
+	if err != nil {
+		return Mp3Tags{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return Mp3Tags{}, err
+	}
+
+	return ReadMp3Tags(f, stat.Size())
+}
+
+// ReadMp3Tags reads ID3v2 tags from the start of r and ID3v1 tags from the end of r, where size is the total size of r.
+// If both exist then ID3v2 values are used, and ID3v1 only fills missing fields.
+//
+// Having no tags is not an error, and an empty Mp3Tags is returned in that case
+func ReadMp3Tags(r io.ReaderAt, size int64) (Mp3Tags, error) {
+
+	tags := Mp3Tags{}
+
+	header := make([]byte, id3v2HeaderSize)
+	if size >= id3v2HeaderSize {
+
+		if _, err := r.ReadAt(header, 0); err != nil {
+			return tags, err
+		}
+
+		if bytes.HasPrefix(header, []byte("ID3")) {
+
+			tagSize, err := id3v2TagSize(header)
+			if err != nil {
+				return tags, err
+			}
+
+			if tagSize > size {
+				tagSize = size
+			}
+
+			tagData := make([]byte, tagSize-id3v2HeaderSize)
+			if _, err := r.ReadAt(tagData, id3v2HeaderSize); err != nil && err != io.EOF {
+				return tags, err
+			}
+
+			parseID3v2(header, tagData, &tags)
+		}
+	}
+
+	if size >= id3v1TagSize {
+
+		v1 := make([]byte, id3v1TagSize)
+		if _, err := r.ReadAt(v1, size-id3v1TagSize); err != nil && err != io.EOF {
+			return tags, err
+		}
+
+		if bytes.HasPrefix(v1, []byte("TAG")) {
+			parseID3v1(v1, &tags)
+		}
+	}
+
+	return tags, nil
+}
+
+// skipMp3Tags returns a reader over r that starts after the ID3v2 tag and ends before the ID3v1 tag (if they exist),
+// so that the MP3 decoder starts at the first audio frame and doesn't waste time reading (potentially large) tags.
+//
+// Tags can only be skipped if r is also an io.ReaderAt (e.g. *os.File and *bytes.Reader), otherwise r is returned at its start
+func skipMp3Tags(r io.ReadSeeker) (io.ReadSeeker, error) {
+
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	ra, ok := r.(io.ReaderAt)
+	if !ok {
+		return r, nil
+	}
+
+	start := int64(0)
+	header := make([]byte, id3v2HeaderSize)
+	if size >= id3v2HeaderSize {
+
+		if _, err := ra.ReadAt(header, 0); err != nil {
+			return nil, err
+		}
+
+		if bytes.HasPrefix(header, []byte("ID3")) {
+
+			start, err = id3v2TagSize(header)
+			if err != nil {
+				return nil, err
+			}
+
+			if start > size {
+				start = size
+			}
+		}
+	}
+
+	end := size
+	if end-start >= id3v1TagSize {
+
+		v1Header := make([]byte, 3)
+		if _, err := ra.ReadAt(v1Header, end-id3v1TagSize); err != nil {
+			return nil, err
+		}
+
+		if string(v1Header) == "TAG" {
+			end -= id3v1TagSize
+		}
+	}
+
+	return io.NewSectionReader(ra, start, end-start), nil
+}
+
+// id3v2TagSize returns the full size of the ID3v2 tag, including the header and the footer (if any)
+func id3v2TagSize(header []byte) (int64, error) {
+
+	if len(header) < id3v2HeaderSize || !bytes.HasPrefix(header, []byte("ID3")) {
+		return 0, errBadID3v2Header
+	}
+
+	size := id3v2HeaderSize + int64(synchsafeToInt(header[6:10]))
+
+	// Footer present flag
+	if header[5]&0x10 != 0 {
+		size += id3v2HeaderSize
+	}
+
+	return size, nil
+}
+
+func parseID3v2(header, data []byte, tags *Mp3Tags) {
+
+	majorVer := header[3]
+	flags := header[5]
+
+	// In v2.4 unsynchronisation is done per frame
+	if flags&0x80 != 0 && majorVer < 4 {
+		data = removeUnsynchronisation(data)
+	}
+
+	// Skip extended header
+	if flags&0x40 != 0 && len(data) >= 4 {
+
+		if majorVer == 4 {
+			data = data[minInt(synchsafeToInt(data[:4]), len(data)):]
+		} else {
+			data = data[minInt(4+int(binary.BigEndian.Uint32(data[:4])), len(data)):]
+		}
+	}
+
+	frameHeaderSize := 10
+	if majorVer == 2 {
+		frameHeaderSize = 6
+	}
+
+	foundFrontCover := false
+	for len(data) >= frameHeaderSize {
+
+		// Reached padding
+		if data[0] == 0 {
+			break
+		}
+
+		var id string
+		var frameSize int
+		var formatFlags byte
+		switch majorVer {
+		case 2:
+			id = string(data[:3])
+			frameSize = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			id = string(data[:4])
+			frameSize = int(binary.BigEndian.Uint32(data[4:8]))
+			formatFlags = data[9]
+		default:
+			id = string(data[:4])
+			frameSize = synchsafeToInt(data[4:8])
+			formatFlags = data[9]
+		}
+
+		data = data[frameHeaderSize:]
+		if frameSize > len(data) {
+			break
+		}
+
+		frame := data[:frameSize]
+		data = data[frameSize:]
+
+		frame, ok := unpackID3v2Frame(majorVer, formatFlags, frame)
+		if !ok || len(frame) == 0 {
+			continue
+		}
+
+		switch id {
+		case "TIT2", "TT2":
+			tags.Title = decodeID3Text(frame[0], frame[1:])
+		case "TPE1", "TP1":
+			tags.Artist = decodeID3Text(frame[0], frame[1:])
+		case "TALB", "TAL":
+			tags.Album = decodeID3Text(frame[0], frame[1:])
+		case "TYER", "TDRC", "TYE":
+			tags.Year = decodeID3Text(frame[0], frame[1:])
+		case "TRCK", "TRK":
+			tags.Track = decodeID3Text(frame[0], frame[1:])
+		case "TCON", "TCO":
+			tags.Genre = decodeID3Text(frame[0], frame[1:])
+		case "COMM", "COM":
+
+			// Encoding, 3 bytes language, description, then the actual text
+			if len(frame) < 4 || tags.Comment != "" {
+				continue
+			}
+
+			_, text := splitID3Terminated(frame[0], frame[4:])
+			tags.Comment = decodeID3Text(frame[0], text)
+		case "APIC", "PIC":
+
+			if foundFrontCover {
+				continue
+			}
+
+			mime, picType, picData, ok := parseID3v2Picture(id, frame)
+			if !ok {
+				continue
+			}
+
+			// Picture type 3 is the front cover
+			if picType == 3 || tags.Artwork == nil {
+				tags.Artwork = picData
+				tags.ArtworkMIME = mime
+				foundFrontCover = picType == 3
+			}
+		}
+	}
+}
+
+// unpackID3v2Frame undoes per-frame transformations. False is returned for frames that can't be read (compressed/encrypted)
+func unpackID3v2Frame(majorVer, formatFlags byte, frame []byte) ([]byte, bool) {
+
+	switch majorVer {
+	case 3:
+
+		// Compression or encryption
+		if formatFlags&0xC0 != 0 {
+			return nil, false
+		}
+
+		// Grouping identity byte
+		if formatFlags&0x20 != 0 && len(frame) > 0 {
+			frame = frame[1:]
+		}
+	case 4:
+
+		// Compression or encryption
+		if formatFlags&0x0C != 0 {
+			return nil, false
+		}
+
+		// Grouping identity byte
+		if formatFlags&0x40 != 0 && len(frame) > 0 {
+			frame = frame[1:]
+		}
+
+		if formatFlags&0x02 != 0 {
+			frame = removeUnsynchronisation(frame)
+		}
+
+		// Data length indicator
+		if formatFlags&0x01 != 0 && len(frame) >= 4 {
+			frame = frame[4:]
+		}
+	}
+
+	return frame, true
+}
+
+func parseID3v2Picture(id string, frame []byte) (mime string, picType byte, picData []byte, ok bool) {
+
+	enc := frame[0]
+	rest := frame[1:]
+
+	if id == "PIC" {
+
+		// 3 byte image format instead of a mime type
+		if len(rest) < 4 {
+			return "", 0, nil, false
+		}
+
+		mime = "image/" + strings.ToLower(string(rest[:3]))
+		if mime == "image/jpg" {
+			mime = "image/jpeg"
+		}
+		rest = rest[3:]
+	} else {
+
+		var mimeBytes []byte
+		mimeBytes, rest = splitID3Terminated(0, rest)
+		mime = string(mimeBytes)
+	}
+
+	if len(rest) < 1 {
+		return "", 0, nil, false
+	}
+
+	picType = rest[0]
+	_, picData = splitID3Terminated(enc, rest[1:])
+
+	return mime, picType, picData, true
+}
+
+func parseID3v1(tag []byte, tags *Mp3Tags) {
+
+	setIfEmpty := func(field *string, value []byte) {
+		if *field == "" {
+			*field = strings.TrimRight(latin1ToString(value), "\x00 ")
+		}
+	}
+
+	setIfEmpty(&tags.Title, tag[3:33])
+	setIfEmpty(&tags.Artist, tag[33:63])
+	setIfEmpty(&tags.Album, tag[63:93])
+	setIfEmpty(&tags.Year, tag[93:97])
+
+	// ID3v1.1 stores the track number in the last byte of the comment
+	comment := tag[97:127]
+	if comment[28] == 0 && comment[29] != 0 {
+
+		if tags.Track == "" {
+			tags.Track = strconv.Itoa(int(comment[29]))
+		}
+		comment = comment[:28]
+	}
+	setIfEmpty(&tags.Comment, comment)
+
+	if tags.Genre == "" && tag[127] != 255 {
+		tags.Genre = strconv.Itoa(int(tag[127]))
+	}
+}
+
+// splitID3Terminated splits b at the first string terminator of the given text encoding,
+// which is two zero bytes for UTF-16 encodings and one zero byte otherwise.
+// If there is no terminator then all of b is returned as the field
+func splitID3Terminated(enc byte, b []byte) (field, rest []byte) {
+
+	if enc == 1 || enc == 2 {
+
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+
+		return b, nil
+	}
+
+	i := bytes.IndexByte(b, 0)
+	if i == -1 {
+		return b, nil
+	}
+
+	return b[:i], b[i+1:]
+}
+
+// decodeID3Text decodes text in one of the ID3v2 encodings: 0=ISO-8859-1, 1=UTF-16 with BOM, 2=UTF-16BE, 3=UTF-8
+func decodeID3Text(enc byte, b []byte) string {
+
+	var s string
+	switch enc {
+	case 0:
+		s = latin1ToString(b)
+	case 1, 2:
+
+		bigEndian := enc == 2
+		if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+			bigEndian = true
+			b = b[2:]
+		} else if len(b) >= 2 && b[0] == 0xFF && b[1] == 0xFE {
+			bigEndian = false
+			b = b[2:]
+		}
+
+		u16s := make([]uint16, len(b)/2)
+		for i := 0; i < len(u16s); i++ {
+			if bigEndian {
+				u16s[i] = binary.BigEndian.Uint16(b[i*2:])
+			} else {
+				u16s[i] = binary.LittleEndian.Uint16(b[i*2:])
+			}
+		}
+
+		s = string(utf16.Decode(u16s))
+	default:
+		s = string(b)
+	}
+
+	return strings.TrimRight(s, "\x00")
+}
+
+func latin1ToString(b []byte) string {
+
+	runes := make([]rune, len(b))
+	for i := 0; i < len(b); i++ {
+		runes[i] = rune(b[i])
+	}
+
+	return string(runes)
+}
+
+// removeUnsynchronisation replaces every 0xFF 0x00 pair with 0xFF
+func removeUnsynchronisation(b []byte) []byte {
+
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+
+		out = append(out, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+
+	return out
+}
+
+// synchsafeToInt decodes a 4 byte synchsafe integer, where only the lower 7 bits of each byte are used
+func synchsafeToInt(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+func minInt(a, b int) int {
+
+	if a < b {
+		return a
+	}
+
+	return b
+}
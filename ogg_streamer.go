@@ -1,30 +1,131 @@
 package wavy
 
 import (
+	"errors"
 	"io"
 	"os"
 
 	"github.com/jfreymuth/oggvorbis"
 )
 
-var _ io.ReadSeeker = &OggStreamer{}
+var (
+	_ io.ReadSeeker = &OggStreamer{}
+	_ OggDecoder    = &oggvorbis.Reader{}
+)
+
+var ErrStreamFormatChanged = errors.New("stream sample rate or channel count changed mid-stream")
+
+// OggDecoder is the set of *oggvorbis.Reader functions used by OggStreamer
+type OggDecoder interface {
+	Read(p []float32) (int, error)
+	Length() int64
+	Position() int64
+	SetPosition(pos int64) error
+	SampleRate() int
+	Channels() int
+}
 
 type OggStreamer struct {
+	readCounter
+
 	F   *os.File
-	Dec *oggvorbis.Reader
+	Dec OggDecoder
+
+	// readerBuf is reused between reads to hold the decoded floats
+	readerBuf []float32
+
+	// sampleRate and chanCount are the format of the stream when it was opened
+	sampleRate int
+	chanCount  int
+
+	// formatChanged is true while reads are failing with ErrStreamFormatChanged
+	formatChanged bool
+
+	eofFlag
+
+	// resampler is set when the stream sample rate is different from the context's, in which case reads go through it
+	// and all positions and sizes are in resampled bytes
+	resampler *pcm16Resampler
+
+	readErrReporter
 }
 
-func (ws *OggStreamer) Read(outBuf []byte) (floatsRead int, err error) {
+// Read decodes into outBuf and returns the number of valid bytes written, which is always twice the number of decoded floats.
+// On short reads the unused part of outBuf is zeroed so no stale data is left in it.
+//
+// Chained OGG files and streams can change sample rate or channel count part way. Since that data can't be played
+// correctly, once the format differs from the one the stream started with reads fail with ErrStreamFormatChanged,
+// which is also sent once on Errors(), so the sound ends instead of playing garbage. Seeking back into the original format recovers.
+//
+// If the stream sample rate is different from the context's then the audio is resampled to the context rate using linear interpolation
+func (ws *OggStreamer) Read(outBuf []byte) (bytesRead int, err error) {
+
+	outBuf = limitToStreamReadBufSize(outBuf)
+	if ws.resampler != nil {
+		bytesRead, err = ws.resampler.Read(outBuf)
+	} else {
+		bytesRead, err = ws.readNative(outBuf)
+	}
+
+	ws.countRead(bytesRead)
+	ws.trackEOF(err)
+	return bytesRead, err
+}
+
+// readNative decodes into outBuf at the stream's own sample rate
+func (ws *OggStreamer) readNative(outBuf []byte) (bytesRead int, err error) {
+
+	outBuf = limitToStreamReadBufSize(outBuf)
+	if cap(ws.readerBuf) < len(outBuf)/2 {
+		ws.readerBuf = make([]float32, len(outBuf)/2)
+	}
+
+	readerBuf := ws.readerBuf[:len(outBuf)/2]
+	floatsRead, err := ws.Dec.Read(readerBuf)
+	if ws.checkFormatChanged(outBuf) {
+		return 0, ErrStreamFormatChanged
+	}
 
-	readerBuf := make([]float32, len(outBuf)/2)
-	floatsRead, err = ws.Dec.Read(readerBuf)
-	F32ToUnsignedPCM16(readerBuf[:floatsRead], outBuf)
+	ws.reportReadErr(err)
+	f32ToPCM16(readerBuf[:floatsRead], outBuf)
+
+	bytesRead = floatsRead * 2
+	for i := bytesRead; i < len(outBuf); i++ {
+		outBuf[i] = 0
+	}
 
-	return floatsRead * 2, err
+	return bytesRead, err
+}
+
+// checkFormatChanged returns true if the stream format differs from the one it started with, in which case
+// outBuf is zeroed and ErrStreamFormatChanged is reported once
+func (ws *OggStreamer) checkFormatChanged(outBuf []byte) bool {
+
+	if ws.Dec.SampleRate() == ws.sampleRate && ws.Dec.Channels() == ws.chanCount {
+		ws.formatChanged = false
+		return false
+	}
+
+	if !ws.formatChanged {
+		ws.formatChanged = true
+		ws.reportReadErr(ErrStreamFormatChanged)
+	}
+
+	for i := 0; i < len(outBuf); i++ {
+		outBuf[i] = 0
+	}
+
+	return true
 }
 
 func (ws *OggStreamer) Seek(offset int64, whence int) (int64, error) {
 
+	// The decoder might have more data after the new position, so any EOF no longer applies
+	ws.clearEOF()
+	if ws.resampler != nil {
+		return ws.resampler.Seek(offset, whence)
+	}
+
 	// This is because ogg expects position in samples not bytes
 	offset /= BytesPerSample
 
@@ -49,14 +150,36 @@ func (ws *OggStreamer) Seek(offset int64, whence int) (int64, error) {
 	return ws.Dec.Position() * BytesPerSample, nil
 }
 
-// Size returns number of bytes
+// Size returns number of bytes, at the context sample rate
 func (ws *OggStreamer) Size() int64 {
+
+	if ws.resampler != nil {
+		return ws.resampler.Size()
+	}
+
 	return ws.Dec.Length() * BytesPerSample
 }
 
-func NewOggStreamer(f *os.File, dec *oggvorbis.Reader) *OggStreamer {
-	return &OggStreamer{
-		F:   f,
-		Dec: dec,
+// CanSeekBackCheaply returns false because seeking has to search the file for the right page then decode from it
+func (ws *OggStreamer) CanSeekBackCheaply() bool {
+	return false
+}
+
+// NewOggStreamer creates a streamer over dec. If wavy is initialized and the stream sample rate is different from
+// the context's then reads are resampled to the context rate
+func NewOggStreamer(f *os.File, dec OggDecoder) *OggStreamer {
+
+	ws := &OggStreamer{
+		F:               f,
+		Dec:             dec,
+		sampleRate:      dec.SampleRate(),
+		chanCount:       dec.Channels(),
+		readErrReporter: readErrReporter{errChan: make(chan error, readErrChanSize)},
 	}
+
+	if SamplingRate > 0 && ws.sampleRate > 0 && SampleRate(ws.sampleRate) != SamplingRate {
+		ws.resampler = newPCM16Resampler(ws.chanCount, int64(ws.sampleRate), int64(SamplingRate), dec.Length(), ws.readNative, dec.SetPosition)
+	}
+
+	return ws
 }
@@ -0,0 +1,141 @@
+package wavy
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+// rampOggDecoder is a stereo OggDecoder whose frame i has the value i/length in both channels
+type rampOggDecoder struct {
+	sampleRate int
+	length     int64
+	pos        int64
+}
+
+func (d *rampOggDecoder) Read(p []float32) (int, error) {
+
+	if d.pos >= d.length {
+		return 0, io.EOF
+	}
+
+	n := 0
+	for ; n+1 < len(p) && d.pos < d.length; n += 2 {
+		p[n] = float32(d.pos) / float32(d.length)
+		p[n+1] = p[n]
+		d.pos++
+	}
+
+	return n, nil
+}
+
+func (d *rampOggDecoder) Length() int64               { return d.length }
+func (d *rampOggDecoder) Position() int64             { return d.pos }
+func (d *rampOggDecoder) SetPosition(pos int64) error { d.pos = pos; return nil }
+func (d *rampOggDecoder) SampleRate() int             { return d.sampleRate }
+func (d *rampOggDecoder) Channels() int               { return 2 }
+
+func TestOggStreamerResample(t *testing.T) {
+
+	oldSamplingRate, oldBytesPerSample, oldBytesPerSecond := SamplingRate, BytesPerSample, BytesPerSecond
+	defer func() {
+		SamplingRate, BytesPerSample, BytesPerSecond = oldSamplingRate, oldBytesPerSample, oldBytesPerSecond
+	}()
+
+	SamplingRate = SampleRate_44100
+	BytesPerSample = 4
+	BytesPerSecond = BytesPerSample * int64(SamplingRate)
+
+	// 2 seconds at 48 kHz must still be 2 seconds at 44.1 kHz
+	ws := NewOggStreamer(nil, &rampOggDecoder{sampleRate: 48000, length: 2 * 48000})
+	if PlayTimeFromByteCount(ws.Size()) != 2*time.Second {
+		t.Errorf("Expected resampled size to be 2s but got '%s'\n", PlayTimeFromByteCount(ws.Size()))
+		return
+	}
+
+	var pcm []byte
+	buf := make([]byte, 4000)
+	for {
+
+		n, err := ws.Read(buf)
+		pcm = append(pcm, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Errorf("Expected no read errors but got '%s'\n", err)
+			return
+		}
+	}
+
+	if int64(len(pcm)) != ws.Size() {
+		t.Errorf("Expected to read %d bytes (2s) but read %d bytes (%s)\n", ws.Size(), len(pcm), PlayTimeFromByteCount(int64(len(pcm))))
+		return
+	}
+
+	// The ramp must stay a ramp, so output frame i is at about i*48000/44100 of the input
+	for _, frame := range []int64{0, 1000, 44100, 88000} {
+
+		expected := float64(frame) * 48000 / 44100 / (2 * 48000) * 32767
+		got := float64(getPCM16Sample(pcm, int(frame*BytesPerSample)))
+		if got < expected-2 || got > expected+2 {
+			t.Errorf("Expected frame %d to be about %.0f but got %.0f\n", frame, expected, got)
+			return
+		}
+	}
+
+	// Seeking is in resampled bytes
+	pos, err := ws.Seek(44100*BytesPerSample, io.SeekStart)
+	if err != nil || pos != 44100*BytesPerSample {
+		t.Errorf("Expected seek to 1s to return %d but got %d. Err: %v\n", 44100*BytesPerSample, pos, err)
+		return
+	}
+
+	ws.Read(buf)
+	if got := getPCM16Sample(buf, 0); got != getPCM16Sample(pcm, int(44100*BytesPerSample)) {
+		t.Errorf("Expected reading after the seek to give the same data as reading from the start, but got %d instead of %d\n", got, getPCM16Sample(pcm, int(44100*BytesPerSample)))
+		return
+	}
+
+	// Matching rates aren't resampled
+	if ws := NewOggStreamer(nil, &rampOggDecoder{sampleRate: 44100, length: 44100}); ws.resampler != nil || ws.Size() != 44100*BytesPerSample {
+		t.Errorf("Expected no resampling when the rates match\n")
+		return
+	}
+}
+
+func TestOggStreamerAtEOF(t *testing.T) {
+
+	oldSamplingRate, oldBytesPerSample, oldBytesPerSecond := SamplingRate, BytesPerSample, BytesPerSecond
+	defer func() {
+		SamplingRate, BytesPerSample, BytesPerSecond = oldSamplingRate, oldBytesPerSample, oldBytesPerSecond
+	}()
+
+	SamplingRate = SampleRate_44100
+	BytesPerSample = 4
+	BytesPerSecond = BytesPerSample * int64(SamplingRate)
+
+	ws := NewOggStreamer(nil, &rampOggDecoder{sampleRate: 44100, length: 100})
+	buf := make([]byte, 4*64)
+	ws.Read(buf)
+	if ws.AtEOF() {
+		t.Errorf("Expected streamer to not be at EOF before reading all the data\n")
+		return
+	}
+
+	for i := 0; i < 10 && !ws.AtEOF(); i++ {
+		ws.Read(buf)
+	}
+
+	if !ws.AtEOF() {
+		t.Errorf("Expected streamer to be at EOF after a read returned io.EOF\n")
+		return
+	}
+
+	ws.Seek(0, io.SeekStart)
+	if ws.AtEOF() {
+		t.Errorf("Expected seeking to clear EOF\n")
+		return
+	}
+}
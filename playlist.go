@@ -0,0 +1,424 @@
+package wavy
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PlaylistTrack describes a track of a Playlist.
+// Index is the position of the track in the paths given to NewPlaylist, and Gain is the gain that was applied to the track to match its loudness to the other tracks
+type PlaylistTrack struct {
+	Path  string
+	Index int
+	Gain  float64
+	Sound *Sound
+}
+
+const (
+	// playlistStartLead is how long before the end of a track the next one is started. A new player takes a moment to fill its buffer
+	// and join the mix, so starting the next track this early makes it follow the previous one without a gap, at the cost of them
+	// overlapping by up to this much
+	playlistStartLead = 10 * time.Millisecond
+
+	// playlistMinGain is the lowest gain loudness matching applies, so that a very loud track isn't made much quieter than the others
+	playlistMinGain = 0.25
+)
+
+// Playlist plays a list of sounds one after the other, with their loudness matched so that switching tracks doesn't
+// change the volume. Tracks are loaded into memory so that there is no loading or decoding gap between them, and each track
+// is started slightly before the previous one ends (see playlistStartLead) so there is no gap while the new player starts.
+//
+// All methods are safe to use from multiple goroutines
+type Playlist struct {
+	lock    sync.Mutex
+	tracks  []PlaylistTrack
+	order   []int
+	pos     int
+	playing bool
+	closed  bool
+
+	// gen is increased every time playback starts or stops, so autoplay goroutines of an older playback know they should exit
+	gen uint64
+}
+
+// NewPlaylist loads the files at 'fpaths' into memory and matches their loudness by bringing every track to the median loudness
+// of the tracks, where loudness is the root mean square of the whole track. Using the median means a single unusually quiet or loud track
+// (e.g. a quiet intro) doesn't change the level of all the others.
+//
+// Gain is never lowered below 0.25 (about -12 dB), and is only raised as far as the loudest sample of the track allows so that
+// loudness matching never causes clipping, which means very quiet tracks might stay quieter than the rest. Silent tracks are left as is.
+//
+// Like LoadDir, a file that fails to load doesn't stop the loading of the rest, and the errors are returned as a MultiError along with
+// a playlist of the tracks that loaded. If no track loads then the returned playlist is nil. Failing to apply the gain of a track
+// is also returned in the MultiError, and that track keeps a gain of one.
+//
+// Panics if fpaths is empty
+func NewPlaylist(fpaths ...string) (*Playlist, error) {
+
+	if len(fpaths) == 0 {
+		panic("playlist needs at least one file")
+	}
+
+	var errs MultiError
+	p := &Playlist{
+		tracks: make([]PlaylistTrack, 0, len(fpaths)),
+	}
+
+	for i, fpath := range fpaths {
+
+		// Partially decoded sounds come with an error but are still usable
+		s, err := NewSoundMem(fpath)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if s != nil {
+			p.tracks = append(p.tracks, PlaylistTrack{Path: fpath, Index: i, Gain: 1, Sound: s})
+		}
+	}
+
+	if len(p.tracks) == 0 {
+		return nil, errs
+	}
+
+	rmsValues := make([]float64, len(p.tracks))
+	peaks := make([]float64, len(p.tracks))
+	for i := 0; i < len(p.tracks); i++ {
+		data := p.tracks[i].Sound.Data.(*SoundBuffer).Data
+		rmsValues[i] = bufferRMS(data)
+		peaks[i] = bufferPeak(data)
+	}
+
+	gains := loudnessMatchGains(rmsValues, peaks)
+	p.order = make([]int, len(p.tracks))
+	for i := 0; i < len(p.tracks); i++ {
+
+		p.order[i] = i
+		if gains[i] == 1 {
+			continue
+		}
+
+		// A track whose gain can't be applied still plays, just without loudness matching
+		if err := ApplyGain(p.tracks[i].Sound, gains[i]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		p.tracks[i].Gain = gains[i]
+	}
+
+	if len(errs) > 0 {
+		return p, errs
+	}
+
+	return p, nil
+}
+
+// Play starts playing the current track from where it was, then plays the following tracks until the end of the playlist.
+// Once the last track finishes the playlist stops and goes back to its first track.
+// Does nothing if the playlist is already playing or is closed
+func (p *Playlist) Play() {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.playing || p.closed {
+		return
+	}
+
+	p.startCurrent()
+}
+
+// Pause pauses the current track and stops autoplay. Play resumes from the same position
+func (p *Playlist) Pause() {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.playing {
+		return
+	}
+
+	p.playing = false
+	p.gen++
+	p.currSound().Pause()
+}
+
+// Next moves to the start of the next track, wrapping around to the first track after the last one.
+// If the playlist is playing then the next track starts playing right away
+func (p *Playlist) Next() {
+	p.move(1)
+}
+
+// Prev moves to the start of the previous track, wrapping around to the last track before the first one.
+// If the playlist is playing then the previous track starts playing right away
+func (p *Playlist) Prev() {
+	p.move(-1)
+}
+
+// Shuffle randomizes the order the tracks are played in. The current track becomes the first one in the new order,
+// so a playing track isn't interrupted and all the other tracks play after it
+func (p *Playlist) Shuffle() {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	curr := p.order[p.pos]
+	rand.Shuffle(len(p.order), func(i, j int) {
+		p.order[i], p.order[j] = p.order[j], p.order[i]
+	})
+
+	for i := 0; i < len(p.order); i++ {
+		if p.order[i] == curr {
+			p.order[0], p.order[i] = p.order[i], p.order[0]
+			break
+		}
+	}
+
+	p.pos = 0
+}
+
+// Current returns the track that is playing, or that would play if Play is called
+func (p *Playlist) Current() PlaylistTrack {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.tracks[p.order[p.pos]]
+}
+
+// CurrentPos returns the position of the current track in the play order, which starts at zero and changes with Shuffle
+func (p *Playlist) CurrentPos() int {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.pos
+}
+
+// Tracks returns the tracks in play order
+func (p *Playlist) Tracks() []PlaylistTrack {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	tracks := make([]PlaylistTrack, len(p.order))
+	for i := 0; i < len(p.order); i++ {
+		tracks[i] = p.tracks[p.order[i]]
+	}
+
+	return tracks
+}
+
+func (p *Playlist) IsPlaying() bool {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.playing
+}
+
+// Close stops playback and closes all the tracks. The playlist must not be used after close
+func (p *Playlist) Close() error {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	p.playing = false
+	p.gen++
+
+	var errs MultiError
+	for i := 0; i < len(p.tracks); i++ {
+		if err := p.tracks[i].Sound.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// move stops the current track and moves 'by' tracks in the play order, wrapping around at both ends
+func (p *Playlist) move(by int) {
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	wasPlaying := p.playing
+	p.playing = false
+	p.gen++
+	p.currSound().Stop()
+
+	p.pos = (p.pos + by) % len(p.order)
+	if p.pos < 0 {
+		p.pos += len(p.order)
+	}
+
+	if wasPlaying {
+		p.startCurrent()
+	}
+}
+
+// startCurrent plays the current track and starts autoplay. Must be called with the lock held
+func (p *Playlist) startCurrent() {
+
+	p.playing = true
+	p.gen++
+
+	// Tracks that played to their end are only rewound when played again, since the end of a track
+	// might still be playing when the next one starts
+	s := p.currSound()
+	if s.AtEnd() {
+		s.Rewind()
+	}
+
+	s.PlayAsync()
+	go p.autoplay(p.gen, s)
+}
+
+// autoplay starts the next track once 's' is about to end, unless playback was changed since 's' started (e.g. by Pause or Next).
+// After the last track it waits for it to finish then stops the playlist.
+//
+// Instead of sleeping in fixed chunks like Sound.Wait, it sleeps half the remaining time each time so that it checks more often the closer
+// the track is to its end, which lets it start the next track within playlistStartLead of the end
+func (p *Playlist) autoplay(gen uint64, s *Sound) {
+
+	for {
+
+		sleepTime := s.RemainingTime() / 2
+		if sleepTime < time.Millisecond {
+			sleepTime = time.Millisecond
+		}
+		clk.Sleep(sleepTime)
+
+		p.lock.Lock()
+		if p.gen != gen {
+			p.lock.Unlock()
+			return
+		}
+
+		hasNext := p.pos+1 < len(p.order)
+		if s.IsPlaying() {
+
+			// Everything left of the track is in the player's buffer, so we start the next one before it drains
+			if hasNext && s.Data.AtEOF() && s.RemainingTime() <= playlistStartLead {
+				p.pos++
+				p.startCurrent()
+				p.lock.Unlock()
+				return
+			}
+
+			p.lock.Unlock()
+			continue
+		}
+
+		// The track was paused directly instead of through the playlist, so we stop autoplay like Pause does
+		if !s.AtEnd() {
+			p.playing = false
+			p.gen++
+			p.lock.Unlock()
+			return
+		}
+
+		if hasNext {
+			p.pos++
+			p.startCurrent()
+		} else {
+			p.pos = 0
+			p.playing = false
+			p.gen++
+		}
+
+		p.lock.Unlock()
+		return
+	}
+}
+
+func (p *Playlist) currSound() *Sound {
+	return p.tracks[p.order[p.pos]].Sound
+}
+
+// bufferRMS returns the root mean square of all the PCM16 samples in data, normalized to [0,1]
+func bufferRMS(data []byte) float64 {
+
+	sampleCount := len(data) / 2
+	if sampleCount == 0 {
+		return 0
+	}
+
+	sumSquares := 0.0
+	for i := 0; i < sampleCount; i++ {
+		x := float64(getPCM16Sample(data, i*2))
+		sumSquares += x * x
+	}
+
+	return math.Sqrt(sumSquares/float64(sampleCount)) / -math.MinInt16
+}
+
+// bufferPeak returns the biggest absolute value of the PCM16 samples in data, normalized to [0,1]
+func bufferPeak(data []byte) float64 {
+
+	peak := 0.0
+	for i := 0; i+1 < len(data); i += 2 {
+		peak = math.Max(peak, math.Abs(float64(getPCM16Sample(data, i))))
+	}
+
+	return peak / -math.MinInt16
+}
+
+// loudnessMatchGains returns the gain of each of the given loudness values that brings it to the median of the values.
+// Gains are at least playlistMinGain, and are limited so the given peak of each value doesn't go over one.
+// Zero values (silence) are ignored and get a gain of one
+func loudnessMatchGains(rmsValues, peaks []float64) []float64 {
+
+	nonSilent := make([]float64, 0, len(rmsValues))
+	for _, rms := range rmsValues {
+		if rms > 0 {
+			nonSilent = append(nonSilent, rms)
+		}
+	}
+
+	gains := make([]float64, len(rmsValues))
+	for i := 0; i < len(gains); i++ {
+		gains[i] = 1
+	}
+
+	if len(nonSilent) == 0 {
+		return gains
+	}
+
+	sort.Float64s(nonSilent)
+	target := nonSilent[len(nonSilent)/2]
+	if len(nonSilent)%2 == 0 {
+		target = (nonSilent[len(nonSilent)/2-1] + target) / 2
+	}
+
+	for i, rms := range rmsValues {
+
+		if rms <= 0 {
+			continue
+		}
+
+		gains[i] = math.Max(target/rms, playlistMinGain)
+		if peaks[i] > 0 && gains[i] > 1/peaks[i] {
+			gains[i] = 1 / peaks[i]
+		}
+	}
+
+	return gains
+}
@@ -0,0 +1,40 @@
+package wavy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoudnessMatchGains(t *testing.T) {
+
+	// The median is 0.2, so the loud track is lowered to the minimum gain and the quiet track is raised as far as its peak allows
+	gains := loudnessMatchGains([]float64{0.9, 0.2, 0, 0.01, 0.2}, []float64{1, 0.4, 0, 0.1, 0.9})
+	expected := []float64{0.25, 1, 1, 10, 1}
+	for i := 0; i < len(expected); i++ {
+		if math.Abs(gains[i]-expected[i]) > 1e-9 {
+			t.Errorf("Expected gain %d to be %f but got %f\n", i, expected[i], gains[i])
+			return
+		}
+	}
+
+	// A full scale square wave has an RMS of one
+	data := make([]byte, 8)
+	putPCM16Sample(data, 0, math.MinInt16)
+	putPCM16Sample(data, 2, math.MinInt16)
+	putPCM16Sample(data, 4, math.MinInt16)
+	putPCM16Sample(data, 6, math.MinInt16)
+	if rms := bufferRMS(data); rms != 1 {
+		t.Errorf("Expected RMS of a full scale signal to be 1 but got %f\n", rms)
+		return
+	}
+
+	if peak := bufferPeak(data); peak != 1 {
+		t.Errorf("Expected peak of a full scale signal to be 1 but got %f\n", peak)
+		return
+	}
+
+	if rms := bufferRMS(nil); rms != 0 {
+		t.Errorf("Expected RMS of empty data to be 0 but got %f\n", rms)
+		return
+	}
+}
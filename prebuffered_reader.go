@@ -0,0 +1,83 @@
+package wavy
+
+import (
+	"io"
+)
+
+// prebufferedReader serves already read bytes from memory before going back to reading from the underlying source.
+// Any seek (other than querying the current position) discards the buffered bytes
+type prebufferedReader struct {
+	Src Source
+	Buf []byte
+
+	// Pos is the position in the coordinates of Src
+	Pos int64
+}
+
+func (pr *prebufferedReader) Read(outBuf []byte) (bytesRead int, err error) {
+
+	if len(pr.Buf) == 0 {
+		bytesRead, err = pr.Src.Read(outBuf)
+		pr.Pos += int64(bytesRead)
+		return bytesRead, err
+	}
+
+	bytesRead = copy(outBuf, pr.Buf)
+	pr.Buf = pr.Buf[bytesRead:]
+	pr.Pos += int64(bytesRead)
+
+	return bytesRead, nil
+}
+
+func (pr *prebufferedReader) Seek(offset int64, whence int) (int64, error) {
+
+	if offset == 0 && whence == io.SeekCurrent {
+		return pr.Pos, nil
+	}
+
+	pr.Buf = nil
+	n, err := pr.Src.Seek(offset, whence)
+	if err != nil {
+		return n, err
+	}
+
+	pr.Pos = n
+	return n, nil
+}
+
+func newPrebufferedReader(src Source, byteCount int64) (*prebufferedReader, error) {
+
+	startPos, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := ReadAllFromReader(io.LimitReader(src, byteCount), 0, uint64(byteCount))
+	if err != nil {
+		return nil, err
+	}
+
+	return &prebufferedReader{
+		Src: src,
+		Buf: buf,
+		Pos: startPos,
+	}, nil
+}
+
+func (pr *prebufferedReader) Size() int64 {
+	return pr.Src.Size()
+}
+
+// BytesRead includes bytes that were prebuffered but not yet read from pr
+func (pr *prebufferedReader) BytesRead() int64 {
+	return pr.Src.BytesRead()
+}
+
+// AtEOF returns true once the prebuffered bytes were read and Src has no more data
+func (pr *prebufferedReader) AtEOF() bool {
+	return len(pr.Buf) == 0 && pr.Src.AtEOF()
+}
+
+func (pr *prebufferedReader) CanSeekBackCheaply() bool {
+	return pr.Src.CanSeekBackCheaply()
+}
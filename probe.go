@@ -0,0 +1,112 @@
+package wavy
+
+import (
+	"os"
+	"time"
+
+	"github.com/go-audio/wav"
+	"github.com/hajimehoshi/go-mp3"
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// ProbeInfo is the format and length of a sound file as read from its headers by ProbeSound
+type ProbeInfo struct {
+	Type       SoundType
+	SampleRate SampleRate
+	ChanCount  SoundChannelCount
+
+	// Duration is zero if the length of the sound can't be known without decoding it (see CanStream)
+	Duration time.Duration
+}
+
+// ProbeSound reads the format and duration of a sound file without decoding its audio, which is much faster than loading it.
+// This is useful for showing information about many files, like the durations of a music library.
+// Wavy doesn't need to be initialized to use this.
+//
+// MP3 files are always reported as stereo since that's what the decoder produces.
+//
+// An error is returned if the file can't be opened or its headers can't be read, or if its type is unknown or not supported
+func ProbeSound(fpath string) (ProbeInfo, error) {
+
+	soundType := GetSoundFileType(fpath)
+	if soundType == SoundType_Unknown {
+		return ProbeInfo{}, errUnknownSoundType
+	}
+
+	if !IsSoundTypeSupported(soundType) {
+		return ProbeInfo{}, ErrUnsupportedSoundType
+	}
+
+	file, err := os.Open(fpath)
+	if err != nil {
+		return ProbeInfo{}, err
+	}
+	defer file.Close()
+
+	info := ProbeInfo{Type: soundType}
+	switch soundType {
+	case SoundType_MP3:
+
+		mp3Src, err := skipMp3Tags(file)
+		if err != nil {
+			return ProbeInfo{}, err
+		}
+
+		dec, err := mp3.NewDecoder(mp3Src)
+		if err != nil {
+			return ProbeInfo{}, err
+		}
+
+		// Decoded MP3 is always 16-bit stereo, and Length is in bytes or negative if unknown
+		info.SampleRate = SampleRate(dec.SampleRate())
+		info.ChanCount = SoundChannelCount_2
+		if dec.Length() > 0 {
+			info.Duration = PlayTimeFromByteCountFmt(dec.Length(), info.SampleRate, info.ChanCount, SoundBitDepth_2)
+		}
+
+	case SoundType_WAV:
+
+		dec := wav.NewDecoder(file)
+		if err := dec.FwdToPCM(); err != nil {
+			return ProbeInfo{}, err
+		}
+
+		info.SampleRate = SampleRate(dec.SampleRate)
+		info.ChanCount = SoundChannelCount(dec.NumChans)
+		info.Duration = PlayTimeFromByteCountFmt(int64(dec.PCMSize), info.SampleRate, info.ChanCount, SoundBitDepth(dec.BitDepth/8))
+
+	case SoundType_OGG:
+
+		dec, err := oggvorbis.NewReader(file)
+		if err != nil {
+			return ProbeInfo{}, err
+		}
+
+		// Length is in samples per channel, and zero if unknown. Durations are computed as if decoded to 16-bit, like the loaders do
+		info.SampleRate = SampleRate(dec.SampleRate())
+		info.ChanCount = SoundChannelCount(dec.Channels())
+		info.Duration = PlayTimeFromByteCountFmt(dec.Length()*int64(dec.Channels())*2, info.SampleRate, info.ChanCount, SoundBitDepth_2)
+	}
+
+	return info, nil
+}
+
+// CanStream probes a sound file and reports whether NewSoundStreaming can stream it with working seeks.
+// This can be used to decide between streaming and loading into memory without trial and error.
+// Wavy doesn't need to be initialized to use this.
+//
+// Streaming seeks depend on the decoder knowing the length of the sound:
+//   - WAV can always be streamed since its length is in the header
+//   - MP3 needs every frame to be readable so the decoder can index them
+//   - OGG needs the last page to have a valid position, which isn't the case for some live recordings or cut files
+//
+// An error is returned if the file can't be opened or decoded, or if its type is unknown or not supported
+func CanStream(fpath string) (bool, error) {
+
+	info, err := ProbeSound(fpath)
+	if err != nil {
+		return false, err
+	}
+
+	return info.Type == SoundType_WAV || info.Duration > 0, nil
+}
@@ -0,0 +1,130 @@
+package wavy
+
+import (
+	"io"
+)
+
+// rangeReader limits reading and seeking of Src to [From, To), and treats reaching To as io.EOF.
+// Positions are in the coordinates of Src, and seeks outside the range are clamped to it.
+// Seeking relative to io.SeekEnd is relative to To.
+//
+// If Loop is true then reaching To seeks Src back to From within the same read, so the range repeats without a gap and never ends
+type rangeReader struct {
+	Src  Source
+	From int64
+	To   int64
+	Pos  int64
+	Loop bool
+}
+
+// AtEOF returns true if the end of the range or of Src was reached. A looping range never ends
+func (rr *rangeReader) AtEOF() bool {
+	return !rr.Loop && (rr.Pos >= rr.To || rr.Src.AtEOF())
+}
+
+func (rr *rangeReader) Read(outBuf []byte) (bytesRead int, err error) {
+
+	if rr.Loop {
+		return rr.readLooping(outBuf)
+	}
+
+	remaining := rr.To - rr.Pos
+	if remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(outBuf)) > remaining {
+		outBuf = outBuf[:remaining]
+	}
+
+	bytesRead, err = rr.Src.Read(outBuf)
+	rr.Pos += int64(bytesRead)
+
+	return bytesRead, err
+}
+
+// readLooping fills outBuf by reading until To then continuing from From.
+// If Src ends before To then that is treated as the end of the range
+func (rr *rangeReader) readLooping(outBuf []byte) (bytesRead int, err error) {
+
+	// Used to avoid spinning forever if the range has nothing to read
+	readSinceWrap := true
+	for bytesRead < len(outBuf) {
+
+		if rr.Pos >= rr.To {
+
+			if !readSinceWrap {
+				return bytesRead, io.EOF
+			}
+
+			if _, err = rr.Src.Seek(rr.From, io.SeekStart); err != nil {
+				return bytesRead, err
+			}
+
+			rr.Pos = rr.From
+			readSinceWrap = false
+		}
+
+		chunk := outBuf[bytesRead:]
+		if remaining := rr.To - rr.Pos; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+
+		n, err := rr.Src.Read(chunk)
+		bytesRead += n
+		rr.Pos += int64(n)
+		if n > 0 {
+			readSinceWrap = true
+		}
+
+		if err == io.EOF {
+			rr.Pos = rr.To
+		} else if err != nil {
+			return bytesRead, err
+		}
+	}
+
+	return bytesRead, nil
+}
+
+func (rr *rangeReader) Seek(offset int64, whence int) (int64, error) {
+
+	newPos := rr.Pos
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos += offset
+	case io.SeekEnd:
+		newPos = rr.To + offset
+	default:
+		return 0, ErrInvalidWhence
+	}
+
+	if newPos < rr.From {
+		newPos = rr.From
+	} else if newPos > rr.To {
+		newPos = rr.To
+	}
+
+	n, err := rr.Src.Seek(newPos, io.SeekStart)
+	if err != nil {
+		return n, err
+	}
+
+	rr.Pos = n
+	return n, nil
+}
+
+// Size returns To, since positions are in the coordinates of Src and nothing after To can be read
+func (rr *rangeReader) Size() int64 {
+	return rr.To
+}
+
+func (rr *rangeReader) BytesRead() int64 {
+	return rr.Src.BytesRead()
+}
+
+func (rr *rangeReader) CanSeekBackCheaply() bool {
+	return rr.Src.CanSeekBackCheaply()
+}
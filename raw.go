@@ -0,0 +1,234 @@
+package wavy
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrInvalidRawFormat = errors.New("raw sound format is not supported. Channel count and bit depth must be 1 or 2")
+
+var _ io.ReadSeeker = &RawStreamer{}
+
+// RawStreamer streams headerless interleaved PCM, converting it to the context format as it's read.
+// Pos and all positions used by Seek are in the context format, so they match the rest of the package
+type RawStreamer struct {
+	readCounter
+
+	F   *os.File
+	Src io.ReadSeeker
+
+	// SrcSize is the size of the raw data in Src, in the raw format
+	SrcSize   int64
+	ChanCount SoundChannelCount
+	BitDepth  SoundBitDepth
+
+	Pos int64
+
+	// readerBuf is reused between reads to hold the raw data
+	readerBuf []byte
+
+	readErrReporter
+}
+
+func (rs *RawStreamer) Read(outBuf []byte) (bytesRead int, err error) {
+
+	outBuf = limitToStreamReadBufSize(outBuf)
+
+	outFrameSize := int(BytesPerSample)
+	inFrameSize := int(rs.ChanCount) * int(rs.BitDepth)
+
+	frames := len(outBuf) / outFrameSize
+	if frames == 0 {
+		return 0, nil
+	}
+
+	if cap(rs.readerBuf) < frames*inFrameSize {
+		rs.readerBuf = make([]byte, frames*inFrameSize)
+	}
+
+	inBuf := rs.readerBuf[:frames*inFrameSize]
+	n, err := io.ReadFull(rs.Src, inBuf)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	rs.reportReadErr(err)
+
+	converted := rawToContextPCM(inBuf[:n-n%inFrameSize], rs.ChanCount, rs.BitDepth)
+	bytesRead = copy(outBuf, converted)
+	rs.Pos += int64(bytesRead)
+	rs.countRead(bytesRead)
+
+	if bytesRead == 0 && err == nil {
+		err = io.EOF
+	}
+
+	return bytesRead, err
+}
+
+func (rs *RawStreamer) Seek(offset int64, whence int) (int64, error) {
+
+	newPos := rs.Pos
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos += offset
+	case io.SeekEnd:
+		newPos = rs.Size() + offset
+	default:
+		return 0, ErrInvalidWhence
+	}
+
+	if newPos < 0 {
+		return 0, ErrNegativeSeekPos
+	}
+
+	newPos = alignToSample(newPos)
+	inFrameSize := int64(rs.ChanCount) * int64(rs.BitDepth)
+	if _, err := rs.Src.Seek(newPos/BytesPerSample*inFrameSize, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	rs.Pos = newPos
+	return newPos, nil
+}
+
+// Size returns number of bytes in the context format
+func (rs *RawStreamer) Size() int64 {
+	inFrameSize := int64(rs.ChanCount) * int64(rs.BitDepth)
+	return rs.SrcSize / inFrameSize * BytesPerSample
+}
+
+// AtEOF returns true if all the raw data after Pos was read
+func (rs *RawStreamer) AtEOF() bool {
+	return rs.Pos >= rs.Size()
+}
+
+func (rs *RawStreamer) CanSeekBackCheaply() bool {
+	return true
+}
+
+// NewSoundMemRaw loads a headerless file of interleaved PCM into memory. Since there is no header the format must be given.
+// Bit depth 1 is treated as unsigned 8-bit PCM, and bit depth 2 as signed little-endian 16-bit PCM.
+// The data is converted to the context channel count, but the sample rate must match the context like other loaders (see SetRateMismatchPolicy).
+//
+// Loading failures are returned as a *LoadError
+func NewSoundMemRaw(fpath string, rate SampleRate, ch SoundChannelCount, depth SoundBitDepth) (*Sound, error) {
+
+	if !isValidRawFormat(ch, depth) {
+		return nil, newLoadError(fpath, SoundType_RAW, ErrInvalidRawFormat)
+	}
+
+	fileInfo, err := os.Stat(fpath)
+	if err != nil {
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	outSize := fileInfo.Size() / (int64(ch) * int64(depth)) * BytesPerSample
+	if err := checkInMemorySize(outSize); err != nil {
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	fileBytes, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	sb := &SoundBuffer{Data: rawToContextPCM(fileBytes, ch, depth)}
+	p := newPlayer(sb)
+	s := &Sound{
+		Player:       p,
+		PlayerSeeker: p.(io.Seeker),
+		Data:         sb,
+		fpath:        fpath,
+		Info: SoundInfo{
+			Type:             SoundType_RAW,
+			Mode:             SoundMode_Memory,
+			Size:             sb.Len(),
+			NativeSampleRate: rate,
+		},
+	}
+	registerMemSound(s)
+
+	if err := checkSampleRate(s); err != nil {
+		s.Close()
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	return s, nil
+}
+
+// NewSoundStreamingRaw is like NewSoundMemRaw but streams the file using a RawStreamer
+func NewSoundStreamingRaw(fpath string, rate SampleRate, ch SoundChannelCount, depth SoundBitDepth) (*Sound, error) {
+
+	if !isValidRawFormat(ch, depth) {
+		return nil, newLoadError(fpath, SoundType_RAW, ErrInvalidRawFormat)
+	}
+
+	file, err := os.Open(fpath)
+	if err != nil {
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	rs := &RawStreamer{
+		F:               file,
+		Src:             file,
+		SrcSize:         fileInfo.Size(),
+		ChanCount:       ch,
+		BitDepth:        depth,
+		readErrReporter: readErrReporter{errChan: make(chan error, readErrChanSize)},
+	}
+
+	p := newPlayer(rs)
+	s := &Sound{
+		Player:       p,
+		PlayerSeeker: p.(io.Seeker),
+		File:         file,
+		Data:         rs,
+		fpath:        fpath,
+		readErrs:     rs.Errors(),
+		Info: SoundInfo{
+			Type:             SoundType_RAW,
+			Mode:             SoundMode_Streaming,
+			Size:             rs.Size(),
+			NativeSampleRate: rate,
+		},
+	}
+
+	if err := checkSampleRate(s); err != nil {
+		s.Close()
+		return nil, newLoadError(fpath, SoundType_RAW, err)
+	}
+
+	return s, nil
+}
+
+func isValidRawFormat(ch SoundChannelCount, depth SoundBitDepth) bool {
+	return (ch == SoundChannelCount_1 || ch == SoundChannelCount_2) && (depth == SoundBitDepth_1 || depth == SoundBitDepth_2)
+}
+
+// rawToContextPCM converts raw interleaved PCM to signed 16-bit PCM with the context channel count.
+// 8-bit input is unsigned, as is standard for 8-bit PCM
+func rawToContextPCM(raw []byte, ch SoundChannelCount, depth SoundBitDepth) []byte {
+
+	pcm16 := raw
+	if depth == SoundBitDepth_1 {
+
+		pcm16 = make([]byte, len(raw)*2)
+		for i, b := range raw {
+			putPCM16Sample(pcm16, i*2, int16(int(b)-128)<<8)
+		}
+	} else if len(raw)%2 != 0 {
+		pcm16 = raw[:len(raw)-1]
+	}
+
+	out := convertChannels(pcm16, int(ch), int(ChanCount))
+	return out[:alignToSample(int64(len(out)))]
+}
@@ -0,0 +1,22 @@
+package wavy
+
+import "sync/atomic"
+
+// readCounter is embedded in sources to count the bytes they returned from Read.
+// Reads happen in the player's goroutine, so the count is atomic.
+//
+// It must be the first field of the struct it's embedded in so the counter is 64-bit aligned on 32-bit platforms
+type readCounter struct {
+	bytesRead int64
+}
+
+func (rc *readCounter) countRead(n int) {
+	if n > 0 {
+		atomic.AddInt64(&rc.bytesRead, int64(n))
+	}
+}
+
+// BytesRead returns the total number of bytes returned by Read so far. Seeking doesn't change it
+func (rc *readCounter) BytesRead() int64 {
+	return atomic.LoadInt64(&rc.bytesRead)
+}
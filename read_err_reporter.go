@@ -0,0 +1,35 @@
+package wavy
+
+import (
+	"io"
+)
+
+const (
+	// readErrChanSize is how many read errors can wait in the channel before new ones are dropped
+	readErrChanSize = 16
+)
+
+// readErrReporter is embedded in streamers to publish errors that happen during Read (e.g. disk or network failures).
+// Those reads happen in the player's goroutine, so without this the errors would not be visible to the user
+type readErrReporter struct {
+	errChan chan error
+}
+
+// reportReadErr publishes err unless it's nil or io.EOF. If the channel is full the error is dropped,
+// because a read must never block on a user that is not receiving
+func (rer *readErrReporter) reportReadErr(err error) {
+
+	if err == nil || err == io.EOF || rer.errChan == nil {
+		return
+	}
+
+	select {
+	case rer.errChan <- err:
+	default:
+	}
+}
+
+// Errors returns a channel that receives errors that happen while reading
+func (rer *readErrReporter) Errors() <-chan error {
+	return rer.errChan
+}
@@ -0,0 +1,157 @@
+package wavy
+
+import (
+	"io"
+)
+
+// pcm16Resampler converts 16-bit PCM read from a source at srcRate to outRate using linear interpolation, and is used
+// by streamers (and decoders of in-memory sounds) whose sample rate is different from the context's.
+//
+// Positions and sizes are in output bytes. Output positions are mapped to source positions from the start of the
+// source with integers rather than accumulated, so rounding errors don't build up over long sounds
+type pcm16Resampler struct {
+
+	// readSrc reads source PCM, seekSrc moves the source to a frame, and srcFrames is the length of the source in frames
+	readSrc   func(p []byte) (int, error)
+	seekSrc   func(frame int64) error
+	srcFrames int64
+
+	frameSize int64
+	srcRate   int64
+	outRate   int64
+
+	// outPos is the output frame the next read starts at, and srcBuf holds source PCM starting at the source frame srcStart.
+	// srcEOF is true once the source has no more data
+	outPos   int64
+	srcBuf   []byte
+	srcStart int64
+	srcEOF   bool
+	readBuf  []byte
+}
+
+func newPCM16Resampler(chanCount int, srcRate, outRate, srcFrames int64, readSrc func(p []byte) (int, error), seekSrc func(frame int64) error) *pcm16Resampler {
+	return &pcm16Resampler{
+		readSrc:   readSrc,
+		seekSrc:   seekSrc,
+		srcFrames: srcFrames,
+		frameSize: int64(chanCount) * 2,
+		srcRate:   srcRate,
+		outRate:   outRate,
+	}
+}
+
+// srcFrameAt returns the source frame that the output frame outFrame starts in
+func (r *pcm16Resampler) srcFrameAt(outFrame int64) int64 {
+	return outFrame * r.srcRate / r.outRate
+}
+
+// Size returns the number of output bytes
+func (r *pcm16Resampler) Size() int64 {
+	return r.srcFrames * r.outRate / r.srcRate * r.frameSize
+}
+
+// Read fills outBuf with whole output frames and zeroes the unused part. io.EOF is returned once the source ends
+// and all of it was resampled. Errors of the source are returned along with whatever could be resampled before them
+func (r *pcm16Resampler) Read(outBuf []byte) (bytesRead int, err error) {
+
+	outFrames := int64(len(outBuf)) / r.frameSize
+
+	// Drop what's before the frame this read starts in
+	if drop := r.srcFrameAt(r.outPos) - r.srcStart; drop > 0 {
+
+		if buffered := int64(len(r.srcBuf)) / r.frameSize; drop > buffered {
+			drop = buffered
+		}
+
+		r.srcBuf = r.srcBuf[:copy(r.srcBuf, r.srcBuf[drop*r.frameSize:])]
+		r.srcStart += drop
+	}
+
+	if len(r.readBuf) < len(outBuf) {
+		r.readBuf = make([]byte, len(outBuf)+int(r.frameSize))
+	}
+
+	// Read until we have the frame after the last one this read needs, so it can be interpolated towards
+	var srcErr error
+	lastNeeded := r.srcFrameAt(r.outPos+outFrames-1) + 1
+	for !r.srcEOF && r.srcStart+int64(len(r.srcBuf))/r.frameSize <= lastNeeded {
+
+		n, err := r.readSrc(r.readBuf)
+		r.srcBuf = append(r.srcBuf, r.readBuf[:n]...)
+		if err == io.EOF || (err == nil && n == 0) {
+			r.srcEOF = true
+		} else if err != nil {
+			srcErr = err
+			break
+		}
+	}
+
+	buffered := int64(len(r.srcBuf)) / r.frameSize
+	for i := int64(0); i < outFrames; i++ {
+
+		srcFrame := r.srcFrameAt(r.outPos)
+		index := srcFrame - r.srcStart
+		if index >= buffered {
+			break
+		}
+
+		// The last frame has nothing after it, so it's used as is
+		nextIndex := index + 1
+		if nextIndex >= buffered {
+			nextIndex = index
+		}
+
+		// How far the output frame is between the two source frames, as a fraction of outRate
+		frac := r.outPos*r.srcRate - srcFrame*r.outRate
+		for c := int64(0); c < r.frameSize; c += 2 {
+			a := int64(getPCM16Sample(r.srcBuf, int(index*r.frameSize+c)))
+			b := int64(getPCM16Sample(r.srcBuf, int(nextIndex*r.frameSize+c)))
+			putPCM16Sample(outBuf, bytesRead+int(c), int16(a+(b-a)*frac/r.outRate))
+		}
+
+		bytesRead += int(r.frameSize)
+		r.outPos++
+	}
+
+	for i := bytesRead; i < len(outBuf); i++ {
+		outBuf[i] = 0
+	}
+
+	if srcErr != nil {
+		return bytesRead, srcErr
+	}
+
+	if r.srcEOF && int64(bytesRead) < outFrames*r.frameSize {
+		return bytesRead, io.EOF
+	}
+
+	return bytesRead, nil
+}
+
+// Seek moves to the output byte given by offset and whence, which is rounded down to a whole frame
+func (r *pcm16Resampler) Seek(offset int64, whence int) (int64, error) {
+
+	outPos := offset / r.frameSize
+	switch whence {
+	case io.SeekCurrent:
+		outPos += r.outPos
+	case io.SeekEnd:
+		outPos += r.Size() / r.frameSize
+	}
+
+	if outPos < 0 {
+		outPos = 0
+	}
+
+	srcPos := r.srcFrameAt(outPos)
+	if err := r.seekSrc(srcPos); err != nil {
+		return r.outPos * r.frameSize, err
+	}
+
+	r.outPos = outPos
+	r.srcBuf = r.srcBuf[:0]
+	r.srcStart = srcPos
+	r.srcEOF = false
+
+	return r.outPos * r.frameSize, nil
+}
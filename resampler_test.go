@@ -0,0 +1,87 @@
+package wavy
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// newRampResampler returns a stereo resampler over 'frames' frames whose values go from 0 up by 'step' per frame
+func newRampResampler(frames, step int, srcRate, outRate int64) *pcm16Resampler {
+
+	pcm := make([]byte, frames*4)
+	for i := 0; i < frames; i++ {
+		putPCM16Sample(pcm, i*4, int16(i*step))
+		putPCM16Sample(pcm, i*4+2, int16(-i*step))
+	}
+
+	src := bytes.NewReader(pcm)
+	seekSrc := func(frame int64) error {
+		_, err := src.Seek(frame*4, io.SeekStart)
+		return err
+	}
+
+	return newPCM16Resampler(2, srcRate, outRate, int64(frames), src.Read, seekSrc)
+}
+
+func TestResampler(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		srcRate int64
+		outRate int64
+	}{
+		{"Down", 48000, 44100},
+		{"Up", 22050, 44100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Half a second at the source rate must still be half a second at the output rate
+			r := newRampResampler(int(tt.srcRate/2), 1, tt.srcRate, tt.outRate)
+			if r.Size() != tt.outRate/2*4 {
+				t.Errorf("Expected size to be %d bytes but got %d\n", tt.outRate/2*4, r.Size())
+				return
+			}
+
+			pcm, err := io.ReadAll(io.LimitReader(r, r.Size()+1000))
+			if err != nil || int64(len(pcm)) != r.Size() {
+				t.Errorf("Expected to read %d bytes but read %d. Err: %v\n", r.Size(), len(pcm), err)
+				return
+			}
+
+			// The ramp goes up by one per source frame, so output frame i must be i*srcRate/outRate
+			for i := 0; i < len(pcm)/4; i++ {
+
+				expected := int16(int64(i) * tt.srcRate / tt.outRate)
+				left, right := getPCM16Sample(pcm, i*4), getPCM16Sample(pcm, i*4+2)
+				if left != expected || right != -expected {
+					t.Errorf("Expected frame %d to be (%d, %d) but got (%d, %d)\n", i, expected, -expected, left, right)
+					return
+				}
+			}
+
+			// Seeking is in output bytes, and reading after a seek gives the same data as reading up to it
+			pos, err := r.Seek(1000*4, io.SeekStart)
+			if err != nil || pos != 1000*4 {
+				t.Errorf("Expected seek to return %d but got %d. Err: %v\n", 1000*4, pos, err)
+				return
+			}
+
+			buf := make([]byte, 400)
+			if n, err := r.Read(buf); n != len(buf) || err != nil || !bytes.Equal(buf, pcm[1000*4:1100*4]) {
+				t.Errorf("Expected reading after the seek to match reading from the start. n=%d, err=%v\n", n, err)
+				return
+			}
+		})
+	}
+
+	// Interpolated values lie between their neighbours
+	r := newRampResampler(10, 100, 2, 3)
+	pcm, _ := io.ReadAll(r)
+	if got := getPCM16Sample(pcm, 4); got != 66 {
+		t.Errorf("Expected output frame 1 to be two thirds of the way from 0 to 100 (66) but got %d\n", got)
+		return
+	}
+}
@@ -0,0 +1,155 @@
+package wavy
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+var _ Source = &seekFadeSource{}
+
+// seekFadeSource is what the player reads from when seek fades are used (see SetSeekFade). A faded seek is only requested here,
+// and is done in the read path: the next read fades out the audio at the current read position, seeks Data, then the following
+// reads fade the new audio in. This ramps the samples themselves, so it doesn't depend on when the audio backend mixes volume changes.
+//
+// Seeks made directly on it (e.g. by the player) cancel any requested or running fade
+type seekFadeSource struct {
+	Source
+
+	lock sync.Mutex
+
+	// pendingPos is the position of a requested faded seek, and is -1 if there is none
+	pendingPos int64
+
+	// fadeBytes is the length of each half of the fade, and fadeInDone is how much of the fade in was read so far
+	fadeBytes  int64
+	fadeInDone int64
+}
+
+func newSeekFadeSource() *seekFadeSource {
+	return &seekFadeSource{pendingPos: -1}
+}
+
+// requestSeek makes the next read fade out over fadeBytes, seek to pos, then fade in over fadeBytes
+func (fs *seekFadeSource) requestSeek(pos, fadeBytes int64) {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	fs.pendingPos = pos
+	fs.fadeBytes = fadeBytes
+	fs.fadeInDone = fadeBytes
+}
+
+func (fs *seekFadeSource) Read(outBuf []byte) (bytesRead int, err error) {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if fs.pendingPos >= 0 {
+
+		pos := fs.pendingPos
+		fs.pendingPos = -1
+
+		// Fade out whatever would have played next, then continue from the new position
+		fadeOut := outBuf
+		if int64(len(fadeOut)) > fs.fadeBytes {
+			fadeOut = fadeOut[:fs.fadeBytes]
+		}
+
+		bytesRead, err = fs.Source.Read(fadeOut)
+		rampPCM16(fadeOut[:bytesRead], 0, int64(bytesRead), true)
+
+		if _, seekErr := fs.Source.Seek(pos, io.SeekStart); seekErr != nil {
+			return bytesRead, seekErr
+		}
+
+		fs.fadeInDone = 0
+		if err == io.EOF {
+			err = nil
+		}
+
+		return bytesRead, err
+	}
+
+	bytesRead, err = fs.Source.Read(outBuf)
+	if fs.fadeInDone < fs.fadeBytes {
+		rampPCM16(outBuf[:bytesRead], fs.fadeInDone, fs.fadeBytes, false)
+		fs.fadeInDone += int64(bytesRead)
+	}
+
+	return bytesRead, err
+}
+
+func (fs *seekFadeSource) Seek(offset int64, whence int) (int64, error) {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if offset != 0 || whence != io.SeekCurrent {
+		fs.pendingPos = -1
+		fs.fadeInDone = fs.fadeBytes
+	}
+
+	return fs.Source.Seek(offset, whence)
+}
+
+// rampPCM16 scales the frames of pcm, which start 'done' bytes into a ramp of rampLen bytes, so that the gain goes from 0 to 1
+// over the ramp, or from 1 to 0 if down is true. Frames past the end of the ramp are left as is
+func rampPCM16(pcm []byte, done, rampLen int64, down bool) {
+
+	if rampLen <= 0 || BytesPerSample <= 0 {
+		return
+	}
+
+	for i := 0; i+int(BytesPerSample) <= len(pcm); i += int(BytesPerSample) {
+
+		pos := done + int64(i)
+		if pos >= rampLen {
+			return
+		}
+
+		gain := float64(pos) / float64(rampLen)
+		if down {
+			gain = 1 - float64(pos+BytesPerSample)/float64(rampLen)
+		}
+
+		for j := i; j < i+int(BytesPerSample); j += 2 {
+			putPCM16Sample(pcm, j, int16(float64(getPCM16Sample(pcm, j))*gain))
+		}
+	}
+}
+
+// seekWithFade requests a faded seek to bytePos that takes about d, without blocking (see seekFadeSource).
+// The sound only moves once the player reads past what it has already buffered, so Position reports the old position till then
+func (s *Sound) seekWithFade(bytePos int64, d time.Duration) error {
+
+	if err := s.installSeekFade(); err != nil {
+		return err
+	}
+
+	fadeBytes := alignToSample(int64(d/2) * BytesPerSecond / int64(time.Second))
+	if fadeBytes < BytesPerSample {
+		fadeBytes = BytesPerSample
+	}
+
+	s.seekFade.requestSeek(bytePos, fadeBytes)
+	return nil
+}
+
+// installSeekFade puts a seekFadeSource between the player and the data if there isn't one already.
+// This recreates the player, so PlayAsync does it before playing when seek fades are on, so that it doesn't happen mid-play
+func (s *Sound) installSeekFade() error {
+
+	if s.seekFade != nil {
+		return nil
+	}
+
+	// The player might hold unplayed bytes, so we move the source to the actual play position so that they get read again
+	if _, err := s.PlayerSeeker.Seek(s.currBytePos(), io.SeekStart); err != nil {
+		return err
+	}
+
+	s.seekFade = newSeekFadeSource()
+	return s.replaceData(s.Data)
+}
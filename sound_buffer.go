@@ -3,6 +3,7 @@ package wavy
 import (
 	"errors"
 	"io"
+	"math"
 )
 
 // Pre-defined errors
@@ -14,6 +15,8 @@ var (
 var _ io.ReadSeeker = &SoundBuffer{}
 
 type SoundBuffer struct {
+	readCounter
+
 	Data []byte
 
 	// Pos is the starting position of the next read
@@ -23,12 +26,17 @@ type SoundBuffer struct {
 // Read only returns io.EOF when bytesRead==0 and no more input is available
 func (sb *SoundBuffer) Read(outBuf []byte) (bytesRead int, err error) {
 
+	if sb.Remaining() == 0 {
+		return 0, io.EOF
+	}
+
 	bytesRead = copy(outBuf, sb.Data[sb.Pos:])
 	if bytesRead == 0 {
 		return 0, io.EOF
 	}
 
 	sb.Pos += int64(bytesRead)
+	sb.countRead(bytesRead)
 	return bytesRead, nil
 }
 
@@ -45,7 +53,7 @@ func (sb *SoundBuffer) Seek(offset int64, whence int) (int64, error) {
 	case io.SeekCurrent:
 		newPos += offset
 	case io.SeekEnd:
-		newPos = int64(len(sb.Data)) + offset
+		newPos = sb.Len() + offset
 	default:
 		return 0, ErrInvalidWhence
 	}
@@ -58,6 +66,40 @@ func (sb *SoundBuffer) Seek(offset int64, whence int) (int64, error) {
 	return sb.Pos, nil
 }
 
+// Reset moves the reading position back to the start of the buffer, and is the same as Seek(0, io.SeekStart)
+func (sb *SoundBuffer) Reset() {
+	sb.Pos = 0
+}
+
+// Len returns the size of the data in bytes
+func (sb *SoundBuffer) Len() int64 {
+	return int64(len(sb.Data))
+}
+
+// Size returns the number of bytes in the buffer, and is the same as Len
+func (sb *SoundBuffer) Size() int64 {
+	return sb.Len()
+}
+
+func (sb *SoundBuffer) CanSeekBackCheaply() bool {
+	return true
+}
+
+// AtEOF returns true if there is nothing left to read after Pos
+func (sb *SoundBuffer) AtEOF() bool {
+	return sb.Remaining() == 0
+}
+
+// Remaining returns the number of bytes left to read after Pos, which is zero if Pos is at or past the end
+func (sb *SoundBuffer) Remaining() int64 {
+
+	if sb.Pos >= sb.Len() {
+		return 0
+	}
+
+	return sb.Len() - sb.Pos
+}
+
 // Copy returns a new SoundBuffer that uses the same `Data` but with an independent ReadSeeker.
 // This allows you to have many readers all reading from different positions of the same buffer.
 //
@@ -68,3 +110,57 @@ func (sb *SoundBuffer) Copy() *SoundBuffer {
 		Pos:  0,
 	}
 }
+
+// Samples returns the entire buffer (regardless of Pos) as signed 16-bit samples, with the channels of each frame next to each other.
+// Any incomplete sample at the end of the data is ignored.
+//
+// The samples are a copy, so this allocates and is meant for analysis rather than hot playback paths
+func (sb *SoundBuffer) Samples() []int16 {
+
+	samples := make([]int16, len(sb.Data)/2)
+	for i := 0; i < len(samples); i++ {
+		samples[i] = getPCM16Sample(sb.Data, i*2)
+	}
+
+	return samples
+}
+
+// SamplesF32 is like Samples but returns the samples as float32 between [-1, 1], which is what FFT/DSP code usually wants.
+// It reverses the mapping of F32ToUnsignedPCM16, so converting the result back with it gives the original data within one step.
+//
+// The samples are a copy, so this allocates and is meant for analysis rather than hot playback paths
+func (sb *SoundBuffer) SamplesF32() []float32 {
+
+	samples := make([]float32, len(sb.Data)/2)
+	for i := 0; i < len(samples); i++ {
+
+		x := getPCM16Sample(sb.Data, i*2)
+		if x < 0 {
+			samples[i] = float32(x) / -math.MinInt16
+		} else {
+			samples[i] = float32(x) / math.MaxInt16
+		}
+	}
+
+	return samples
+}
+
+// WriteWav writes the entire buffer (regardless of Pos) to w as a PCM WAV file.
+// The data is written as is, so rate, ch and depth must describe it. For buffers of loaded sounds that is the context format (see Init).
+//
+// Any incomplete frame at the end of the data is not written
+func (sb *SoundBuffer) WriteWav(w io.Writer, rate SampleRate, ch SoundChannelCount, depth SoundBitDepth) error {
+
+	bytesPerFrame := int(ch) * int(depth)
+	if bytesPerFrame <= 0 || rate <= 0 {
+		return errors.New("invalid wav format. Sample rate, channel count and bit depth must be bigger than zero")
+	}
+
+	data := sb.Data[:len(sb.Data)-len(sb.Data)%bytesPerFrame]
+	if _, err := w.Write(makeWavHeader(int64(len(data)), rate, ch, depth)); err != nil {
+		return err
+	}
+
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,48 @@
+package wavy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSoundBufferSamples(t *testing.T) {
+
+	values := []int16{0, 1, -1, 1000, -1000, math.MaxInt16, math.MinInt16, 12345, -12345}
+	data := make([]byte, len(values)*2+1)
+	for i, x := range values {
+		putPCM16Sample(data, i*2, x)
+	}
+
+	// The trailing byte is an incomplete sample and must be ignored
+	sb := &SoundBuffer{Data: data, Pos: 4}
+
+	samples := sb.Samples()
+	if len(samples) != len(values) {
+		t.Errorf("Expected %d samples but got %d\n", len(values), len(samples))
+		return
+	}
+
+	for i := range values {
+		if samples[i] != values[i] {
+			t.Errorf("Expected sample %d to be %d but got %d\n", i, values[i], samples[i])
+			return
+		}
+	}
+
+	fs := sb.SamplesF32()
+	if fs[5] != 1 || fs[6] != -1 || fs[0] != 0 {
+		t.Errorf("Expected the int16 range to map to [-1, 1] but got %f and %f for the extremes, and %f for zero\n", fs[6], fs[5], fs[0])
+		return
+	}
+
+	// Converting back must give the original data within one step
+	roundTrip := F32ToUnsignedPCM16(fs, nil)
+	for i := range values {
+
+		diff := int32(getPCM16Sample(roundTrip, i*2)) - int32(values[i])
+		if diff < -1 || diff > 1 {
+			t.Errorf("Expected sample %d to round trip to %d within one step but got %d\n", i, values[i], getPCM16Sample(roundTrip, i*2))
+			return
+		}
+	}
+}
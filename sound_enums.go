@@ -7,8 +7,40 @@ const (
 	SoundType_MP3
 	SoundType_WAV
 	SoundType_OGG
+
+	// soundType_OPUS is recognized but can't be decoded yet, so loading it returns ErrUnsupportedSoundType.
+	// It's unexported till there is a decoder so the API doesn't suggest OPUS can be loaded
+	soundType_OPUS
+
+	// SoundType_RAW is headerless PCM, which is only loaded by the raw loaders (e.g. NewSoundMemRaw) since the format must be given
+	SoundType_RAW
+
+	// soundType_AAC is AAC audio, either raw (.aac) or in an MP4 container (.m4a). It is recognized but can't be decoded yet
+	// since there is no pure Go AAC decoder we can depend on, so loading it returns ErrUnsupportedSoundType.
+	// Like soundType_OPUS it's unexported till there is a decoder
+	soundType_AAC
 )
 
+func (t SoundType) String() string {
+
+	switch t {
+	case SoundType_MP3:
+		return "MP3"
+	case SoundType_WAV:
+		return "WAV"
+	case SoundType_OGG:
+		return "OGG"
+	case soundType_OPUS:
+		return "OPUS"
+	case SoundType_RAW:
+		return "RAW"
+	case soundType_AAC:
+		return "AAC"
+	default:
+		return "Unknown"
+	}
+}
+
 type SampleRate int
 
 const (
@@ -36,3 +68,118 @@ const (
 	SoundMode_Streaming SoundMode = iota
 	SoundMode_Memory
 )
+
+func (m SoundMode) String() string {
+
+	switch m {
+	case SoundMode_Streaming:
+		return "Streaming"
+	case SoundMode_Memory:
+		return "Memory"
+	default:
+		return "Unknown"
+	}
+}
+
+type OverrunPolicy int
+
+const (
+	// OverrunPolicy_Block makes writes wait until there is space
+	OverrunPolicy_Block OverrunPolicy = iota
+
+	// OverrunPolicy_Drop makes writes discard whatever doesn't fit
+	OverrunPolicy_Drop
+)
+
+type SoundEventType int
+
+const (
+	// SoundEventType_Started is sent when a sound starts playing, including the first pass of a loop
+	SoundEventType_Started SoundEventType = iota
+
+	// SoundEventType_Finished is sent when a sound stops because it reached its end, or when a loop ends after its last pass
+	SoundEventType_Finished
+
+	// SoundEventType_Looped is sent each time a looping sound starts a new pass
+	SoundEventType_Looped
+
+	// SoundEventType_Paused is sent when a sound is paused, which includes stopping it
+	SoundEventType_Paused
+)
+
+func (t SoundEventType) String() string {
+
+	switch t {
+	case SoundEventType_Started:
+		return "Started"
+	case SoundEventType_Finished:
+		return "Finished"
+	case SoundEventType_Looped:
+		return "Looped"
+	case SoundEventType_Paused:
+		return "Paused"
+	default:
+		return "Unknown"
+	}
+}
+
+type RateMismatchPolicy int
+
+const (
+	// RateMismatchPolicy_Error makes loading fail with ErrSampleRateMismatch
+	RateMismatchPolicy_Error RateMismatchPolicy = iota
+
+	// RateMismatchPolicy_PlayAnyway loads the sound, which then plays at the wrong speed and pitch
+	RateMismatchPolicy_PlayAnyway
+
+	// RateMismatchPolicy_Warn is like RateMismatchPolicy_PlayAnyway but logs a warning
+	RateMismatchPolicy_Warn
+)
+
+type FadeCurve int
+
+const (
+	// FadeCurve_Linear changes the volume by the same amount every step
+	FadeCurve_Linear FadeCurve = iota
+
+	// FadeCurve_Exponential changes the volume by the same number of decibels every step, which sounds
+	// more even than linear because loudness is perceived logarithmically. Usually the best choice for music
+	FadeCurve_Exponential
+
+	// FadeCurve_SCurve starts and ends slowly and is fastest in the middle
+	FadeCurve_SCurve
+)
+
+func (c FadeCurve) String() string {
+
+	switch c {
+	case FadeCurve_Linear:
+		return "Linear"
+	case FadeCurve_Exponential:
+		return "Exponential"
+	case FadeCurve_SCurve:
+		return "SCurve"
+	default:
+		return "Unknown"
+	}
+}
+
+type BackwardSeekPolicy int
+
+const (
+	// BackwardSeekPolicy_Allow performs backward seeks on all sounds, even if the source has to search and decode to get there
+	BackwardSeekPolicy_Allow BackwardSeekPolicy = iota
+
+	// BackwardSeekPolicy_Error makes backward seeks on sounds that can't seek backward cheaply fail with ErrExpensiveBackwardSeek
+	BackwardSeekPolicy_Error
+)
+
+type SeekOvershootPolicy int
+
+const (
+	// SeekOvershootPolicy_Clamp moves seeks past the end of a sound to its end
+	SeekOvershootPolicy_Clamp SeekOvershootPolicy = iota
+
+	// SeekOvershootPolicy_Error makes seeks past the end of a sound fail with ErrSeekPastEnd
+	SeekOvershootPolicy_Error
+)
@@ -0,0 +1,60 @@
+package wavy
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+var (
+	_ Source = &SoundBuffer{}
+	_ Source = &WavStreamer{}
+	_ Source = &OggStreamer{}
+	_ Source = &Mp3Streamer{}
+	_ Source = &RawStreamer{}
+	_ Source = &StreamSink{}
+	_ Source = &rangeReader{}
+	_ Source = &prebufferedReader{}
+)
+
+// Source is where a sound reads its PCM from, and is implemented by both the in-memory SoundBuffer and the streamers
+type Source interface {
+	io.ReadSeeker
+
+	// Size returns the number of PCM bytes in the source, in the format wavy was initialized with.
+	// It is zero if the size is not known
+	Size() int64
+
+	// CanSeekBackCheaply reports whether seeking backwards is fast enough to do frequently (e.g. once per loop).
+	// This is true for memory and WAV, but false for formats that have to search for and decode from a page boundary
+	CanSeekBackCheaply() bool
+
+	// BytesRead returns the total number of bytes returned by Read so far, which keeps growing across seeks and loops
+	BytesRead() int64
+
+	// AtEOF reports whether the source has no more data to read from its current position.
+	// Sources that only find out when their decoder returns io.EOF report true once a read got io.EOF, until the next seek
+	AtEOF() bool
+}
+
+// eofFlag is embedded in sources that can only tell they reached the end when their decoder returns io.EOF.
+// Reads happen in the player's goroutine, so the flag is atomic
+type eofFlag struct {
+	eof int32
+}
+
+// trackEOF sets the flag if err is io.EOF
+func (ef *eofFlag) trackEOF(err error) {
+	if err == io.EOF {
+		atomic.StoreInt32(&ef.eof, 1)
+	}
+}
+
+// clearEOF clears the flag, and is called after seeks
+func (ef *eofFlag) clearEOF() {
+	atomic.StoreInt32(&ef.eof, 0)
+}
+
+// AtEOF returns true if a read got io.EOF since the last seek
+func (ef *eofFlag) AtEOF() bool {
+	return atomic.LoadInt32(&ef.eof) == 1
+}
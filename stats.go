@@ -0,0 +1,102 @@
+package wavy
+
+import "time"
+
+// SoundStats are playback statistics of a sound.
+// PlayCount is the number of times playback was started (e.g. PlayAsync or LoopAsync), LoopCount is the number of
+// extra passes played by loops, and TotalPlayedDuration is the total time the sound spent playing
+type SoundStats struct {
+	PlayCount           int
+	LoopCount           int
+	TotalPlayedDuration time.Duration
+}
+
+// Stats returns the playback statistics of the sound since it was loaded or since the last ResetStats
+func (s *Sound) Stats() SoundStats {
+
+	s.statsLock.Lock()
+	defer s.statsLock.Unlock()
+
+	stats := s.stats
+	if !s.playStartTime.IsZero() {
+		stats.TotalPlayedDuration += clk.Now().Sub(s.playStartTime)
+	}
+
+	return stats
+}
+
+// BytesRead returns the number of bytes the player has read from the sound's data, which can be compared with Info.Size
+// or BytesPerSecond to monitor buffering (e.g. a streaming sound whose count stops growing while playing is stalled).
+// Seeks and loops don't reset it, and the player reads ahead of what is heard by up to its buffer size.
+//
+// Functions that replace the data of an in-memory sound (e.g. SetStereoWidth and the filters) restart the count.
+// Returns zero after close
+func (s *Sound) BytesRead() int64 {
+
+	if s.IsClosed() {
+		return 0
+	}
+
+	return s.Data.BytesRead()
+}
+
+// BufferedDuration returns how much audio the player has read but not played yet, which is the current output backlog/latency of the sound.
+// This is useful for syncing things to what is actually heard, and for detecting underruns (the value dropping to zero while playing).
+// Returns zero after close
+func (s *Sound) BufferedDuration() time.Duration {
+
+	if s.IsClosed() {
+		return 0
+	}
+
+	return PlayTimeFromByteCount(int64(s.Player.UnplayedBufferSize()))
+}
+
+// ResetStats sets all the playback statistics to zero. If the sound is playing then the played duration counts from now
+func (s *Sound) ResetStats() {
+
+	s.statsLock.Lock()
+	defer s.statsLock.Unlock()
+
+	s.stats = SoundStats{}
+	if !s.playStartTime.IsZero() {
+		s.playStartTime = clk.Now()
+	}
+}
+
+// recordStats updates the statistics based on an event
+func (s *Sound) recordStats(t SoundEventType) {
+
+	switch t {
+	case SoundEventType_Started, SoundEventType_Looped:
+
+		s.statsLock.Lock()
+		if t == SoundEventType_Started {
+			s.stats.PlayCount++
+		} else {
+			s.stats.LoopCount++
+		}
+
+		if s.playStartTime.IsZero() {
+			s.playStartTime = clk.Now()
+		}
+		s.statsLock.Unlock()
+
+	case SoundEventType_Paused, SoundEventType_Finished:
+		s.stopPlayTimer()
+	}
+}
+
+// stopPlayTimer adds the time since playback started to the played duration
+func (s *Sound) stopPlayTimer() {
+
+	s.statsLock.Lock()
+	defer s.statsLock.Unlock()
+
+	if s.playStartTime.IsZero() {
+		return
+	}
+
+	s.stats.TotalPlayedDuration += clk.Now().Sub(s.playStartTime)
+	s.playStartTime = time.Time{}
+}
@@ -0,0 +1,241 @@
+package wavy
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	ErrStreamSinkClosed      = errors.New("stream sink is closed")
+	ErrStreamSinkOverrun     = errors.New("stream sink is full and some data was dropped")
+	ErrStreamSinkNotSeekable = errors.New("stream sink can only report its position and can not seek")
+)
+
+var (
+	_ io.ReadSeeker = &StreamSink{}
+	_ io.Writer     = &StreamSink{}
+	_ io.Closer     = &StreamSink{}
+)
+
+// StreamSink is a ring buffer that producers write PCM into (in the same format wavy was initialized with),
+// and that can be played as a sound (see NewSoundStreamSink), which makes playing generated audio in real-time possible.
+//
+// If the player reads faster than producers write (underrun) then silence is played for the missing data.
+// If producers write faster than the player reads (overrun) then writes either block or drop data based on the OverrunPolicy.
+//
+// StreamSink is safe to write to from one goroutine while it's being played
+type StreamSink struct {
+	lock    sync.Mutex
+	notFull *sync.Cond
+
+	buf []byte
+
+	// readIndex is where the oldest unread byte is, and count is how many unread bytes there are
+	readIndex int
+	count     int
+
+	policy OverrunPolicy
+	closed bool
+
+	// pos is the number of bytes read so far, including silence
+	pos int64
+}
+
+// NewStreamSink creates a sink that can hold up to 'capacity' bytes that are not yet played.
+// Bigger capacities allow producers to get further ahead, but add latency.
+//
+// Panics if capacity<=0
+func NewStreamSink(capacity int, policy OverrunPolicy) *StreamSink {
+
+	if capacity <= 0 {
+		panic("stream sink capacity must be bigger than zero")
+	}
+
+	ss := &StreamSink{
+		buf:    make([]byte, capacity),
+		policy: policy,
+	}
+	ss.notFull = sync.NewCond(&ss.lock)
+
+	return ss
+}
+
+// Write adds PCM data to the sink.
+//
+// With OverrunPolicy_Block the write waits until all of p fits, or until the sink is closed.
+// With OverrunPolicy_Drop the write never waits, and whatever doesn't fit (rounded to whole samples) is dropped
+// and ErrStreamSinkOverrun is returned along with the number of bytes that were written.
+//
+// Writing to a closed sink returns ErrStreamSinkClosed
+func (ss *StreamSink) Write(p []byte) (n int, err error) {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	for len(p) > 0 {
+
+		if ss.closed {
+			return n, ErrStreamSinkClosed
+		}
+
+		free := len(ss.buf) - ss.count
+		if free == 0 {
+
+			if ss.policy == OverrunPolicy_Drop {
+				return n, ErrStreamSinkOverrun
+			}
+
+			ss.notFull.Wait()
+			continue
+		}
+
+		toWrite := len(p)
+		if toWrite > free {
+
+			toWrite = free
+
+			// Dropping part of a sample would shift all the following samples, so only whole samples are written
+			if ss.policy == OverrunPolicy_Drop && BytesPerSample > 0 {
+				toWrite -= toWrite % int(BytesPerSample)
+			}
+		}
+
+		writeIndex := (ss.readIndex + ss.count) % len(ss.buf)
+		written := copy(ss.buf[writeIndex:], p[:toWrite])
+		written += copy(ss.buf, p[written:toWrite])
+
+		ss.count += written
+		n += written
+		p = p[written:]
+
+		if ss.policy == OverrunPolicy_Drop && len(p) > 0 {
+			return n, ErrStreamSinkOverrun
+		}
+	}
+
+	return n, nil
+}
+
+// Read fills outBuf with written data, and fills anything missing with silence.
+// io.EOF is only returned once the sink is closed and all its data has been read
+func (ss *StreamSink) Read(outBuf []byte) (bytesRead int, err error) {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	if ss.closed && ss.count == 0 {
+		return 0, io.EOF
+	}
+
+	toRead := len(outBuf)
+	if toRead > ss.count {
+		toRead = ss.count
+	}
+
+	readEnd := ss.readIndex + toRead
+	if readEnd <= len(ss.buf) {
+		copy(outBuf, ss.buf[ss.readIndex:readEnd])
+	} else {
+		firstPart := copy(outBuf, ss.buf[ss.readIndex:])
+		copy(outBuf[firstPart:], ss.buf[:toRead-firstPart])
+	}
+
+	if toRead > 0 {
+		ss.readIndex = readEnd % len(ss.buf)
+		ss.count -= toRead
+		ss.notFull.Broadcast()
+	}
+
+	// Underrun, so we play silence for what's missing
+	for i := toRead; i < len(outBuf); i++ {
+		outBuf[i] = 0
+	}
+
+	ss.pos += int64(len(outBuf))
+	return len(outBuf), nil
+}
+
+// Seek can only be used to get the current position (i.e. Seek(0, io.SeekCurrent)), which is the number
+// of bytes read so far including silence. Any other seek returns ErrStreamSinkNotSeekable
+func (ss *StreamSink) Seek(offset int64, whence int) (int64, error) {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	if offset != 0 || whence != io.SeekCurrent {
+		return ss.pos, ErrStreamSinkNotSeekable
+	}
+
+	return ss.pos, nil
+}
+
+// Size always returns zero since a sink has no fixed length
+func (ss *StreamSink) Size() int64 {
+	return 0
+}
+
+// CanSeekBackCheaply returns false since a sink can't seek at all
+func (ss *StreamSink) CanSeekBackCheaply() bool {
+	return false
+}
+
+// BytesRead returns the number of bytes read so far, including silence
+func (ss *StreamSink) BytesRead() int64 {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	return ss.pos
+}
+
+// AtEOF returns true once the sink is closed and all its data has been read
+func (ss *StreamSink) AtEOF() bool {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	return ss.closed && ss.count == 0
+}
+
+// Buffered returns the number of written bytes that are not yet read
+func (ss *StreamSink) Buffered() int {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	return ss.count
+}
+
+// Close stops the sink from accepting new writes and wakes up any blocked writers.
+// Data already in the sink can still be read, after which reads return io.EOF.
+// Repeated calls are no-ops
+func (ss *StreamSink) Close() error {
+
+	ss.lock.Lock()
+	defer ss.lock.Unlock()
+
+	ss.closed = true
+	ss.notFull.Broadcast()
+
+	return nil
+}
+
+// NewSoundStreamSink creates a streaming sound that plays whatever is written to the sink.
+// Since the sound has no fixed length TotalTime and RemainingTime always return zero.
+//
+// Closing the sound also closes the sink
+func NewSoundStreamSink(sink *StreamSink) *Sound {
+
+	p := newPlayer(sink)
+	return &Sound{
+		Player:       p,
+		PlayerSeeker: p.(io.Seeker),
+		Data:         sink,
+		Info: SoundInfo{
+			Type:             SoundType_Unknown,
+			Mode:             SoundMode_Streaming,
+			NativeSampleRate: SamplingRate,
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package wavy
+
+import (
+	"io"
+	"sync"
+)
+
+var _ Source = &teeSource{}
+
+// teeSource is what the player reads from when a sound has a tee (see Sound.Tee). It reads from the sound's Data and
+// writes everything read to W, while seeking and the other Source methods go to the Data unchanged.
+//
+// The first write error (other than ErrStreamSinkOverrun) stops the teeing and is kept in err, because failing
+// the read would stop playback over a problem with the capture
+type teeSource struct {
+	Source
+
+	lock sync.Mutex
+	W    io.Writer
+	err  error
+}
+
+func (ts *teeSource) Read(outBuf []byte) (bytesRead int, err error) {
+
+	bytesRead, err = ts.Source.Read(outBuf)
+	if bytesRead == 0 {
+		return bytesRead, err
+	}
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if ts.err != nil {
+		return bytesRead, err
+	}
+
+	if _, writeErr := ts.W.Write(outBuf[:bytesRead]); writeErr != nil && writeErr != ErrStreamSinkOverrun {
+		ts.err = writeErr
+	}
+
+	return bytesRead, err
+}
+
+func (ts *teeSource) Err() error {
+
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	return ts.err
+}
+
+// Tee makes everything the player reads from now on also be written to w, so the same audio can be played and captured
+// (e.g. to a file) or sent to a second output at the same time. To play it elsewhere use a StreamSink as w and play it
+// with NewSoundStreamSink, ideally with OverrunPolicy_Drop so a slow second output can never block this sound.
+//
+// The player reads ahead of what is audible, so w gets data slightly before it's heard. Seeking and looping are followed,
+// which means w gets whatever is read after the seek. Passing a nil w removes the tee.
+//
+// The first error returned by w (other than ErrStreamSinkOverrun) stops the teeing without affecting playback, and is returned by TeeErr.
+// w is not closed when the sound is closed.
+//
+// An error is returned if the sound is closed
+func (s *Sound) Tee(w io.Writer) error {
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	// The player might hold unplayed bytes, so we move the source to the actual play position so that they get read (and teed) again
+	if _, err := s.PlayerSeeker.Seek(s.currBytePos(), io.SeekStart); err != nil {
+		return err
+	}
+
+	if w == nil {
+		s.tee = nil
+	} else {
+		s.tee = &teeSource{W: w}
+	}
+
+	return s.replaceData(s.Data)
+}
+
+// TeeErr returns the error that stopped the current tee, or nil if there is no tee or it's working
+func (s *Sound) TeeErr() error {
+
+	if s.tee == nil {
+		return nil
+	}
+
+	return s.tee.Err()
+}
+
+// playerSource returns what the player of s should read from given the sound data.
+// The tee is outermost so that it captures the audio as played, including seek fades
+func (s *Sound) playerSource(data Source) Source {
+
+	src := data
+	if s.seekFade != nil {
+		s.seekFade.Source = src
+		src = s.seekFade
+	}
+
+	if s.tee != nil {
+		s.tee.Source = src
+		src = s.tee
+	}
+
+	return src
+}
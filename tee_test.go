@@ -0,0 +1,73 @@
+package wavy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+type failingWriter struct {
+	writes int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	fw.writes++
+	return 0, errors.New("write failed")
+}
+
+func TestTeeSource(t *testing.T) {
+
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	captured := &bytes.Buffer{}
+	ts := &teeSource{Source: &SoundBuffer{Data: data}, W: captured}
+
+	buf := make([]byte, 3)
+	read := &bytes.Buffer{}
+	for {
+
+		n, err := ts.Read(buf)
+		read.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if !bytes.Equal(read.Bytes(), data) || !bytes.Equal(captured.Bytes(), data) {
+		t.Errorf("Expected both the reader and the tee to get %v, but reader got %v and tee got %v\n", data, read.Bytes(), captured.Bytes())
+		return
+	}
+
+	// Seeks are passed through, and data read after the seek is teed again
+	ts.Seek(6, io.SeekStart)
+	ts.Read(buf)
+	if !bytes.Equal(captured.Bytes()[len(data):], data[6:]) {
+		t.Errorf("Expected tee to get %v after the seek but got %v\n", data[6:], captured.Bytes()[len(data):])
+		return
+	}
+
+	// A failing writer stops the tee but not the reads
+	fw := &failingWriter{}
+	ts = &teeSource{Source: &SoundBuffer{Data: data}, W: fw}
+	for i := 0; i < 2; i++ {
+
+		if n, err := ts.Read(buf); n != len(buf) || err != nil {
+			t.Errorf("Expected reads to work with a failing tee writer but got n=%d and err=%v\n", n, err)
+			return
+		}
+	}
+
+	if ts.Err() == nil || fw.writes != 1 {
+		t.Errorf("Expected the first write error to be kept and to stop further writes, but err=%v and writes=%d\n", ts.Err(), fw.writes)
+		return
+	}
+
+	// Overruns of a sink with OverrunPolicy_Drop are expected and don't stop the tee
+	sink := NewStreamSink(2, OverrunPolicy_Drop)
+	ts = &teeSource{Source: &SoundBuffer{Data: data}, W: sink}
+	ts.Read(buf)
+	if ts.Err() != nil || sink.Buffered() != 2 {
+		t.Errorf("Expected sink overrun to be ignored but err=%v and sink has %d bytes\n", ts.Err(), sink.Buffered())
+		return
+	}
+}
@@ -0,0 +1,111 @@
+package wavy
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const (
+	wavHeaderSize = 44
+)
+
+var _ io.ReadSeeker = &wavReader{}
+
+// wavReader presents a header followed by PCM data as one seekable stream without copying the PCM data
+type wavReader struct {
+	Header []byte
+	PCM    []byte
+
+	// Pos is the starting position of the next read
+	Pos int64
+}
+
+func (wr *wavReader) Read(outBuf []byte) (bytesRead int, err error) {
+
+	headerLen := int64(len(wr.Header))
+	if wr.Pos < headerLen {
+		bytesRead = copy(outBuf, wr.Header[wr.Pos:])
+	}
+
+	if bytesRead < len(outBuf) && wr.Pos+int64(bytesRead) >= headerLen {
+		pcmPos := wr.Pos + int64(bytesRead) - headerLen
+		if pcmPos < int64(len(wr.PCM)) {
+			bytesRead += copy(outBuf[bytesRead:], wr.PCM[pcmPos:])
+		}
+	}
+
+	if bytesRead == 0 && len(outBuf) > 0 {
+		return 0, io.EOF
+	}
+
+	wr.Pos += int64(bytesRead)
+	return bytesRead, nil
+}
+
+func (wr *wavReader) Seek(offset int64, whence int) (int64, error) {
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += wr.Pos
+	case io.SeekEnd:
+		offset += int64(len(wr.Header) + len(wr.PCM))
+	default:
+		return 0, ErrInvalidWhence
+	}
+
+	if offset < 0 {
+		return 0, ErrNegativeSeekPos
+	}
+
+	wr.Pos = offset
+	return offset, nil
+}
+
+// WavReader returns a seekable reader that presents the PCM data of the sound as a complete WAV file (header + data),
+// which is useful for passing the sound to libraries that accept WAV readers.
+// The format of the WAV is the format of the context (see Init), and the PCM data is shared with the sound, not copied.
+//
+// The reader always covers the entire sound regardless of the current play position.
+// ErrNotInMemory is returned if the sound is not in-memory, and ErrSoundClosed if it's closed
+func (s *Sound) WavReader() (io.ReadSeeker, error) {
+
+	if s.IsClosed() {
+		return nil, ErrSoundClosed
+	}
+
+	if s.Info.Mode != SoundMode_Memory {
+		return nil, ErrNotInMemory
+	}
+
+	pcm := s.Data.(*SoundBuffer).Data
+	return &wavReader{
+		Header: makeWavHeader(int64(len(pcm)), SamplingRate, ChanCount, BitDepth),
+		PCM:    pcm,
+	}, nil
+}
+
+// makeWavHeader returns the 44-byte header of a PCM WAV file with the given format
+func makeWavHeader(pcmSize int64, rate SampleRate, ch SoundChannelCount, depth SoundBitDepth) []byte {
+
+	bytesPerFrame := int64(ch) * int64(depth)
+	h := make([]byte, wavHeaderSize)
+
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], uint32(wavHeaderSize-8+pcmSize))
+	copy(h[8:], "WAVE")
+
+	copy(h[12:], "fmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], uint16(ch))
+	binary.LittleEndian.PutUint32(h[24:], uint32(rate))
+	binary.LittleEndian.PutUint32(h[28:], uint32(bytesPerFrame*int64(rate)))
+	binary.LittleEndian.PutUint16(h[32:], uint16(bytesPerFrame))
+	binary.LittleEndian.PutUint16(h[34:], uint16(depth)*8)
+
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], uint32(pcmSize))
+
+	return h
+}
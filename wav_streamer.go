@@ -10,50 +10,85 @@ import (
 var _ io.ReadSeeker = &WavStreamer{}
 
 type WavStreamer struct {
-	F        *os.File
-	Dec      *wav.Decoder
-	Pos      int64
+	readCounter
+
+	F   *os.File
+	Dec *wav.Decoder
+
+	// Pos is the current position relative to the start of the PCM data, so Pos=0 is the first byte of sound.
+	// All positions used/returned by Seek are in the same coordinates
+	Pos int64
+
+	// PCMStart is the offset of the PCM data within the file
 	PCMStart int64
+
+	readErrReporter
 }
 
 func (ws *WavStreamer) Read(outBuf []byte) (bytesRead int, err error) {
 
+	// The decoder doesn't stop at the end of the PCM data, so we limit reads ourselves
+	// to avoid playing any chunks that might come after it
+	remaining := ws.Size() - ws.Pos
+	if remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	outBuf = limitToStreamReadBufSize(outBuf)
+	if int64(len(outBuf)) > remaining {
+		outBuf = outBuf[:remaining]
+	}
+
 	bytesRead, err = ws.Dec.PCMChunk.Read(outBuf)
 	ws.Pos += int64(bytesRead)
+	ws.countRead(bytesRead)
+	ws.reportReadErr(err)
 
 	return bytesRead, err
 }
 
+// AtEOF returns true if all the PCM data after Pos was read
+func (ws *WavStreamer) AtEOF() bool {
+	return ws.Pos >= ws.Size()
+}
+
+// Seek moves to a position relative to the start of the PCM data
 func (ws *WavStreamer) Seek(offset int64, whence int) (int64, error) {
 
-	// This will only seek the underlying file but not the actual decoder because it can't seek
-	n, err := ws.Dec.Seek(offset, whence)
-	if err != nil {
-		return n, err
+	newPos := ws.Pos
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos += offset
+	case io.SeekEnd:
+		newPos = ws.Size() + offset
+	default:
+		return 0, ErrInvalidWhence
 	}
 
-	// Since underlying decoder can't seek back, if the requested movement is back we have to rewind the decoder
-	// then seek forward to the requested position.
-	if n < ws.Pos {
+	if newPos < 0 {
+		return 0, ErrNegativeSeekPos
+	}
 
-		err = ws.Dec.Rewind()
+	// Since the underlying decoder can't seek back, if the requested movement is back we have to rewind the decoder
+	// first, then seek forward to the requested position
+	if newPos < ws.Pos {
+
+		err := ws.Dec.Rewind()
 		if err != nil {
 			return 0, err
 		}
+	}
 
-		// Anything before PCMStart is not valid sound, so the minimum seek back we allow is PCMStart
-		if n < ws.PCMStart {
-			n = ws.PCMStart
-		} else {
-			n, err = ws.Dec.Seek(offset, whence)
-			if err != nil {
-				return n, err
-			}
-		}
+	// This will only seek the underlying file but not the actual decoder because it can't seek
+	_, err := ws.Dec.Seek(ws.PCMStart+newPos, io.SeekStart)
+	if err != nil {
+		return 0, err
 	}
 
-	ws.Pos = n
-	return n, err
+	ws.Pos = newPos
+	return newPos, nil
 }
 
 // Size returns number of bytes
@@ -61,6 +96,10 @@ func (ws *WavStreamer) Size() int64 {
 	return ws.Dec.PCMLen()
 }
 
+func (ws *WavStreamer) CanSeekBackCheaply() bool {
+	return true
+}
+
 func NewWavStreamer(f *os.File, wavDec *wav.Decoder) (*WavStreamer, error) {
 
 	err := wavDec.FwdToPCM()
@@ -69,15 +108,16 @@ func NewWavStreamer(f *os.File, wavDec *wav.Decoder) (*WavStreamer, error) {
 	}
 
 	// The actual data starts somewhat within the file, not at 0
-	currPos, err := wavDec.Seek(0, 1)
+	pcmStart, err := wavDec.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return nil, err
 	}
 
 	return &WavStreamer{
-		F:        f,
-		Dec:      wavDec,
-		Pos:      currPos,
-		PCMStart: currPos,
+		F:               f,
+		Dec:             wavDec,
+		Pos:             0,
+		PCMStart:        pcmStart,
+		readErrReporter: readErrReporter{errChan: make(chan error, readErrChanSize)},
 	}, nil
 }
@@ -5,9 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"math"
+	"math/rand"
 	"os"
 	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-audio/wav"
@@ -22,9 +27,25 @@ type SoundInfo struct {
 	Mode SoundMode
 
 	Size int64
+
+	// NativeSampleRate is the sample rate of the sound file itself, which might differ from the rate passed to Init.
+	// Sounds that are resampled to the context rate (see SetRateMismatchPolicy) still report the rate of the file
+	NativeSampleRate SampleRate
 }
 
 type Sound struct {
+	// The int64/uint64 fields are kept first so their 64-bit atomic operations are aligned on 32-bit platforms
+	id uint64
+
+	// fadeGen is incremented by every fade, so running fades can tell they were replaced
+	fadeGen uint64
+
+	// remainingLoops is the number of passes left for the running loop, and is negative for infinite loops
+	remainingLoops int64
+
+	// currentLoop is the 1-based pass the running loop is on, and zero when not looping
+	currentLoop int64
+
 	Player       oto.Player
 	PlayerSeeker io.Seeker
 	Info         SoundInfo
@@ -33,13 +54,69 @@ type Sound struct {
 	// This is only set if sound is streamed, and is kept to ensure GC doesn't hit it
 	File *os.File
 
-	// Data is an io.ReadSeeker over an open file or over a buffer containing the uncompressed sound file.
+	// Data is where the player reads PCM from, which is a streamer over an open file or a SoundBuffer containing the uncompressed sound.
 	// Becomes nil after close
-	Data io.ReadSeeker
+	Data Source
 
 	IsLooping bool
+
+	// fpath is the path of the file the sound was loaded from, if any
+	fpath string
+
+	// readErrs is the Errors() channel of the streamer, and is nil for sounds that can't have read errors
+	readErrs <-chan error
+
+	// finishWatched is 1 while a goroutine is waiting to publish SoundEventType_Finished
+	finishWatched int32
+
+	// statsLock guards the playback statistics. playStartTime is zero while not playing
+	statsLock     sync.Mutex
+	stats         SoundStats
+	playStartTime time.Time
+
+	// unmap releases the memory mapping of sounds loaded with NewSoundMmap, and is nil for other sounds
+	unmap func() error
+
+	// mem counts the data of in-memory sounds towards TotalInMemoryBytes, and is nil for other sounds.
+	// dryMem keeps dryData counted while the sound has a group effect. Both are guarded by memBlocksLock
+	mem    *memBlock
+	dryMem *memBlock
+
+	// playLock makes the check and play of PlayIfNotPlaying and Retrigger atomic
+	playLock sync.Mutex
+
+	// duckLock guards the duck state used by Duck
+	duckLock          sync.Mutex
+	duckCount         int
+	duckRestoreVolume float64
+
+	// group is the name of the group the sound is in (see SetGroup), and groupPaused is true if PauseGroup paused it.
+	// Both are guarded by groupsLock
+	group       string
+	groupPaused bool
+
+	// dryData is the data of an in-memory sound from before its group effect was applied (see SetGroupEffect),
+	// and is nil if there is no group effect. Guarded by groupsLock
+	dryData *SoundBuffer
+
+	// hooksLock guards onPlay and onPause (see SetOnPlay and SetOnPause)
+	hooksLock sync.Mutex
+	onPlay    func()
+	onPause   func()
+
+	// tee is set while the sound has a tee (see Tee), and is what the player reads from instead of Data
+	tee *teeSource
+
+	// seekFade is set once the sound is used with seek fades (see SetSeekFade), and is between the player and Data
+	seekFade *seekFadeSource
 }
 
+var (
+	_ io.Closer    = &Sound{}
+	_ io.WriterTo  = &Sound{}
+	_ fmt.Stringer = &Sound{}
+)
+
 // Those values are set after Init
 var (
 	Ctx *oto.Context
@@ -52,32 +129,303 @@ var (
 	BytesPerSecond int64
 )
 
+// ctxLock guards operations on Ctx. oto's context does its own locking in the versions we use,
+// but it doesn't document NewPlayer as safe for concurrent use, so we don't rely on it.
+// ctxSuspended is true between PauseAllSounds and ResumeAllSounds, and is also guarded by ctxLock
+var (
+	ctxLock      sync.Mutex
+	ctxSuspended bool
+)
+
+const (
+	MinStreamReadBufferSize = 4096
+
+	// DefaultMaxInMemoryBytes is the default limit set by SetMaxInMemoryBytes, which is 1 GiB
+	DefaultMaxInMemoryBytes = 1 << 30
+
+	// DefaultSeekFadeDuration is a good seek fade length to use with SetSeekFade. It is short enough to not be heard as a fade
+	DefaultSeekFadeDuration = 4 * time.Millisecond
+)
+
+// Package settings. Use the setter functions to change them
+var (
+	ditheringEnabled       = false
+	streamReadBufSize      = 0
+	allowPartialDecode     = false
+	hqFloatConversion      = false
+	rateMismatchPolicy     = RateMismatchPolicy_Error
+	maxInMemoryBytes       = int64(DefaultMaxInMemoryBytes)
+	readBufPooling         = false
+	backwardSeekPolicy     = BackwardSeekPolicy_Allow
+	seekOvershootPolicy    = SeekOvershootPolicy_Clamp
+	seekFadeDuration       = time.Duration(0)
+	unexpectedEOFIsPartial = false
+	zeroCrossingWindow     = time.Duration(0)
+)
+
+// readBufPool holds the temporary read buffers of ReadAllFromReader when pooling is enabled
+var readBufPool = sync.Pool{}
+
+// PartialDecodeError is returned along with a playable sound when partial decoding is allowed (see SetAllowPartialDecode)
+// and decoding failed part way, or when data was truncated and SetUnexpectedEOFAsPartial is enabled. Err is the decoding error
+type PartialDecodeError struct {
+	Err error
+}
+
+func (e *PartialDecodeError) Error() string {
+	return "sound was only partially decoded: " + e.Err.Error()
+}
+
+func (e *PartialDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// LoadError is returned when loading a sound fails. Err is the underlying cause,
+// so for example os.IsNotExist(loadErr.Err) tells whether the file is missing
+type LoadError struct {
+	Path string
+	Type SoundType
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("failed to load '%s' with err '%s'", e.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
 // Pre-defined errors
 var (
-	errUnknownSoundType = errors.New("unknown sound type. Sound file extension must be one of: .mp3")
+	errUnknownSoundType = errors.New("unknown sound type. Sound file extension must be one of: " + strings.Join(SupportedExtensions(), ", "))
+
+	ErrUnsupportedSoundType = errors.New("decoding this sound type is not supported")
+
+	ErrSoundClosed  = errors.New("sound is closed")
+	ErrSoundPlaying = errors.New("operation not allowed while the sound is playing")
+	ErrNotInMemory  = errors.New("operation is only supported for in-memory sounds")
+
+	ErrSampleRateMismatch = errors.New("sound sample rate is different from the context sample rate")
+	ErrFileTooLarge       = errors.New("sound is too large to be loaded into memory. Please use NewSoundStreaming instead or increase the limit with SetMaxInMemoryBytes")
+
+	ErrExpensiveBackwardSeek = errors.New("sound can not seek backward cheaply and the backward seek policy is BackwardSeekPolicy_Error")
+	ErrSeekPastEnd           = errors.New("seek position is past the end of the sound and the seek overshoot policy is SeekOvershootPolicy_Error")
 )
 
 // Init prepares the default audio device and does any required setup.
-// It must be called before loading any sounds
+// It must be called before loading any sounds, and blocks until the device is ready (see InitAsync for a non-blocking version)
 func Init(sr SampleRate, chanCount SoundChannelCount, bitDepth SoundBitDepth) error {
+	return <-InitAsync(sr, chanCount, bitDepth)
+}
+
+// InitAsync is like Init but returns immediately. The returned channel receives nil once the device is ready,
+// or the error if initialization failed, and is then closed.
+//
+// Sounds must not be loaded until the channel receives nil, as the package globals (e.g. Ctx) are only set then
+func InitAsync(sr SampleRate, chanCount SoundChannelCount, bitDepth SoundBitDepth) <-chan error {
+
+	errChan := make(chan error, 1)
 
 	otoCtx, readyChan, err := oto.NewContext(int(sr), int(chanCount), int(bitDepth))
 	if err != nil {
-		return err
+		errChan <- err
+		close(errChan)
+		return errChan
 	}
-	<-readyChan
 
-	Ctx = otoCtx
-	SamplingRate = sr
-	ChanCount = chanCount
-	BitDepth = bitDepth
+	go func() {
+
+		<-readyChan
+
+		ctxLock.Lock()
+		Ctx = otoCtx
+		SamplingRate = sr
+		ChanCount = chanCount
+		BitDepth = bitDepth
+
+		BytesPerSample = int64(chanCount) * int64(bitDepth)
+		BytesPerSecond = BytesPerSample * int64(SamplingRate)
+		ctxLock.Unlock()
+
+		errChan <- nil
+		close(errChan)
+	}()
+
+	return errChan
+}
+
+// SetDithering controls whether TPDF dithering is applied when converting float audio (e.g. OGG) to PCM16.
+// Dithering trades the correlated quantization distortion of quiet passages for a small amount of noise.
+//
+// Default is false, which keeps the conversion bit-exact
+func SetDithering(enabled bool) {
+	ditheringEnabled = enabled
+}
+
+// SetStreamReadBufferSize sets the max number of bytes the streamers (e.g. WavStreamer and OggStreamer) decode per Read call.
+// This only limits how much each read returns, so the player simply makes more reads to fill its buffer.
+// It doesn't change the size of the player's buffer, so it doesn't change output latency or throughput,
+// but it does bound how long a single read can block the player (e.g. on a slow decoder).
+//
+// n<=0 removes the limit (the default), in which case the size of the buffer the player passes is used.
+// If 0<n<MinStreamReadBufferSize then MinStreamReadBufferSize is used. n is rounded down to a multiple of 4 so reads stay sample aligned
+func SetStreamReadBufferSize(n int) {
 
-	BytesPerSample = int64(chanCount) * int64(bitDepth)
-	BytesPerSecond = BytesPerSample * int64(SamplingRate)
+	if n <= 0 {
+		streamReadBufSize = 0
+		return
+	}
+
+	if n < MinStreamReadBufferSize {
+		n = MinStreamReadBufferSize
+	}
+
+	streamReadBufSize = n - n%4
+}
+
+// SetHQFloatConversion controls whether float sources (e.g. OGG) are converted to PCM16 using F32ToPCM16HQ instead of F32ToUnsignedPCM16.
+// See F32ToPCM16HQ for the quality/speed tradeoff. Default is false.
+//
+// If dithering is enabled (see SetDithering) then it takes priority, since dithering already rounds and saturates
+func SetHQFloatConversion(enabled bool) {
+	hqFloatConversion = enabled
+}
+
+// SetAllowPartialDecode controls what in-memory loaders (e.g. NewSoundMem) do when decoding fails part way (e.g. a truncated or slightly corrupt file).
+// When false (the default), the loader fails and returns no sound.
+// When true, and some audio was decoded before the failure, the loader returns a sound made of the decoded part along with
+// an error wrapping a *PartialDecodeError, which should be treated as a warning
+func SetAllowPartialDecode(allow bool) {
+	allowPartialDecode = allow
+}
+
+// SetUnexpectedEOFAsPartial controls whether io.ErrUnexpectedEOF is treated as the end of truncated but usable data.
+// Some decoders return it for files that were cut short (e.g. an interrupted download), even though everything before the cut is fine.
+//
+// When true, ReadAllFromReader returns the data read before io.ErrUnexpectedEOF along with a *PartialDecodeError,
+// and in-memory loaders return a playable sound with an error wrapping it, which should be treated as a warning.
+// This applies even if SetAllowPartialDecode is false, since only this specific error is accepted.
+//
+// Default is false, which makes io.ErrUnexpectedEOF fail like any other error
+func SetUnexpectedEOFAsPartial(enabled bool) {
+	unexpectedEOFIsPartial = enabled
+}
+
+// SetRateMismatchPolicy controls what loaders do when a sound's native sample rate is different from the context's (see Init).
+// Such a sound plays at the wrong speed and pitch, for example a 48000 Hz file in a 44100 Hz context plays about 9% slower and lower.
+// MP3 and streaming OGG sounds are the exception, since they are resampled to the context rate and so always play correctly.
+//
+// The default is RateMismatchPolicy_Error, which makes loaders return an error wrapping ErrSampleRateMismatch
+func SetRateMismatchPolicy(policy RateMismatchPolicy) {
+	rateMismatchPolicy = policy
+}
+
+// SetBackwardSeekPolicy controls what seeks do when they go backward on a sound that can't seek backward cheaply (see Sound.CanSeekBackward),
+// like a streaming OGG sound which has to search the file for the right page then decode from it.
+//
+// With BackwardSeekPolicy_Error such seeks don't move the sound, and SeekToPercentErr, SeekToTimeErr and SeekByErr return ErrExpensiveBackwardSeek.
+// Rewind, Stop and looping always seek since they are explicit restarts.
+//
+// The default is BackwardSeekPolicy_Allow
+func SetBackwardSeekPolicy(policy BackwardSeekPolicy) {
+	backwardSeekPolicy = policy
+}
+
+// SetSeekOvershootPolicy controls what SeekToTime and SeekToTimeErr do when given a time past the end of a sound.
+//
+// With SeekOvershootPolicy_Error such seeks don't move the sound, and SeekToTimeErr returns ErrSeekPastEnd.
+// Seeking exactly to the end is always allowed, and SeekToPercent and SeekBy always clamp.
+//
+// The default is SeekOvershootPolicy_Clamp
+func SetSeekOvershootPolicy(policy SeekOvershootPolicy) {
+	seekOvershootPolicy = policy
+}
+
+// SetSeekFade makes seeks on playing sounds fade out over half of d, seek, then fade back in over the other half.
+// This removes the click caused by the waveform jumping, which is especially noticeable while scrubbing.
+// DefaultSeekFadeDuration is a good value to use.
+//
+// The fade is applied to the samples as the player reads them, so seeks don't block. As a result the jump is heard,
+// and shows in Position, once the player plays what it has already buffered, which is the same delay as starting a sound.
+//
+// Using d<=0 disables seek fades, which is the default
+func SetSeekFade(d time.Duration) {
+	seekFadeDuration = d
+}
+
+// SetZeroCrossingSnap makes seeks on in-memory sounds (e.g. SeekToTime and SeekToPercent) move to the nearest zero crossing
+// within 'window' of the requested position, which reduces the click caused by the waveform jumping without needing a fade (see SetSeekFade).
+// This means seeks can land up to 'window' before or after the requested position. A window of a few milliseconds is usually enough,
+// since most audio crosses zero many times per millisecond. If there is no zero crossing in the window then the position isn't changed.
+//
+// Streaming sounds (including their playback and loop ranges) are not affected since their PCM isn't available before decoding.
+// Using window<=0 disables snapping, which is the default
+func SetZeroCrossingSnap(window time.Duration) {
+	zeroCrossingWindow = window
+}
+
+// SetReadBufferPooling controls whether ReadAllFromReader (used by the in-memory loaders) reuses its temporary read buffer
+// across calls through a sync.Pool. This reduces allocations and GC work when loading many sounds, especially with big reading buffers.
+// The returned data is never pooled and is always owned by the caller.
+//
+// Default is false
+func SetReadBufferPooling(enabled bool) {
+	readBufPooling = enabled
+}
+
+// SetMaxInMemoryBytes sets the largest sound in bytes that in-memory loaders (e.g. NewSoundMem) accept.
+// Both the file size and, when the decoder can tell it before decoding, the decoded size are checked,
+// and loading larger sounds fails with an error wrapping ErrFileTooLarge instead of running out of memory.
+//
+// n<=0 removes the limit. The default is DefaultMaxInMemoryBytes
+func SetMaxInMemoryBytes(n int64) {
+	maxInMemoryBytes = n
+}
+
+// checkInMemorySize returns ErrFileTooLarge if byteCount is over the SetMaxInMemoryBytes limit
+func checkInMemorySize(byteCount int64) error {
+
+	if maxInMemoryBytes > 0 && byteCount > maxInMemoryBytes {
+		return fmt.Errorf("%w (%d bytes while the limit is %d bytes)", ErrFileTooLarge, byteCount, maxInMemoryBytes)
+	}
 
 	return nil
 }
 
+// checkSampleRate applies the rate mismatch policy to a loaded sound
+func checkSampleRate(s *Sound) error {
+
+	if s.Info.NativeSampleRate == 0 || s.Info.NativeSampleRate == SamplingRate {
+		return nil
+	}
+
+	// MP3 and streaming OGG are resampled to the context rate, so they play correctly
+	if s.Info.Type == SoundType_MP3 || (s.Info.Type == SoundType_OGG && s.Info.Mode == SoundMode_Streaming) {
+		return nil
+	}
+
+	switch rateMismatchPolicy {
+	case RateMismatchPolicy_PlayAnyway:
+		return nil
+	case RateMismatchPolicy_Warn:
+		log.Printf("wavy: sound '%s' has a sample rate of %d Hz but the context uses %d Hz, so it will play at the wrong speed\n", s.fpath, s.Info.NativeSampleRate, SamplingRate)
+		return nil
+	default:
+		return fmt.Errorf("%w: sound is %d Hz but context is %d Hz", ErrSampleRateMismatch, s.Info.NativeSampleRate, SamplingRate)
+	}
+}
+
+// limitToStreamReadBufSize shortens buf to the size set by SetStreamReadBufferSize, if any
+func limitToStreamReadBufSize(buf []byte) []byte {
+
+	if streamReadBufSize > 0 && len(buf) > streamReadBufSize {
+		return buf[:streamReadBufSize]
+	}
+
+	return buf
+}
+
 // Wait blocks until sound finishes playing. If the sound is not playing Wait returns immediately.
 // In the worst case (Wait sleeping then sound immediately paused), Wait will block ~4% of the total play time.
 // In most other cases Wait should be accurate to ~1ms.
@@ -91,13 +439,16 @@ func (s *Sound) Wait() {
 
 	// We wait the remaining time in 25 chunks so that if the sound was paused since wait was called we don't keep blocking
 	sleepTime := s.RemainingTime() / 25
+	if sleepTime < time.Millisecond {
+		sleepTime = time.Millisecond
+	}
 	for s.Player.IsPlaying() {
-		time.Sleep(sleepTime)
+		clk.Sleep(sleepTime)
 	}
 
 	// If there is anything left it should be tiny so we check frequently
 	for s.Player.IsPlaying() {
-		time.Sleep(time.Millisecond)
+		clk.Sleep(time.Millisecond)
 	}
 }
 
@@ -110,8 +461,44 @@ func (s *Sound) WaitLoop() {
 }
 
 // PlayAsync plays the sound in the background and returns.
+// The OnPlay hook (see SetOnPlay) runs before playing starts
 func (s *Sound) PlayAsync() {
+
+	// Installing the seek fade recreates the player, which can't be heard while it isn't playing
+	if seekFadeDuration > 0 && !s.Player.IsPlaying() {
+		s.installSeekFade()
+	}
+
+	s.callOnPlay()
 	s.Player.Play()
+	s.publishEvent(SoundEventType_Started)
+	s.watchForFinish()
+}
+
+// PlayIfNotPlaying plays the sound only if it isn't already playing, and returns true if it started playing.
+// This is useful for sounds like UI clicks that shouldn't restart when triggered again while playing.
+// The check and play are atomic, so two goroutines calling this at once can't both start the sound
+func (s *Sound) PlayIfNotPlaying() bool {
+
+	s.playLock.Lock()
+	defer s.playLock.Unlock()
+
+	if s.IsPlaying() {
+		return false
+	}
+
+	s.PlayAsync()
+	return true
+}
+
+// Retrigger moves the sound back to its start and plays it, whether or not it was already playing
+func (s *Sound) Retrigger() {
+
+	s.playLock.Lock()
+	defer s.playLock.Unlock()
+
+	s.Rewind()
+	s.PlayAsync()
 }
 
 // PlaySync calls PlayAsync() followed by Wait()
@@ -125,6 +512,36 @@ func (s *Sound) PlaySync() {
 // If timesToPlay==0 then the sound is not played.
 // If a sound is already playing then it will be paused then resumed in a looping manner
 func (s *Sound) LoopAsync(timesToPlay int) {
+	s.LoopWithGapAsync(timesToPlay, 0)
+}
+
+// LoopWithGapAsync is like LoopAsync but waits 'gap' of silence between the end of one play and the start of the next.
+// A gap<=0 behaves exactly like LoopAsync
+func (s *Sound) LoopWithGapAsync(timesToPlay int, gap time.Duration) {
+	s.loopAsync(timesToPlay, gap, nil)
+}
+
+// LoopWithVolumes plays the sound once per entry in volumes, setting the volume to volumes[i] before the i-th play.
+// For example, []float64{0.25, 0.5, 1} plays the sound three times getting louder each time.
+//
+// Volumes must be between 0 and 1 (both inclusive), otherwise this panics like SetVolume.
+// Looping behaves the same as LoopAsync, and when looping ends the volume is left at the last used value
+func (s *Sound) LoopWithVolumes(volumes []float64) {
+
+	for i := 0; i < len(volumes); i++ {
+		if volumes[i] < 0 || volumes[i] > 1 {
+			panic("sound volume can not be less than zero or bigger than one")
+		}
+	}
+
+	s.loopAsync(len(volumes), 0, func(iteration int) {
+		s.SetVolume(volumes[iteration])
+	})
+}
+
+// loopAsync implements the looping functions. If beforePlay is not nil it is called before every play
+// with the index of that play (starting at zero)
+func (s *Sound) loopAsync(timesToPlay int, gap time.Duration, beforePlay func(iteration int)) {
 
 	if timesToPlay == 0 {
 		return
@@ -140,45 +557,127 @@ func (s *Sound) LoopAsync(timesToPlay int) {
 		}
 	}
 
-	s.PlayAsync()
-	timesToPlay--
+	if beforePlay == nil {
+		beforePlay = func(iteration int) {}
+	}
+
+	// Negative means infinite, so we keep it at -1 so it never reaches zero
+	if timesToPlay < 0 {
+		atomic.StoreInt64(&s.remainingLoops, -1)
+	} else {
+		atomic.StoreInt64(&s.remainingLoops, int64(timesToPlay-1))
+	}
+
+	atomic.StoreInt64(&s.currentLoop, 1)
+	s.callOnPlay()
+	beforePlay(0)
 	s.IsLooping = true
+	s.Player.Play()
+	s.publishEvent(SoundEventType_Started)
 	go func() {
 
-		if timesToPlay < 0 {
+		iteration := 1
+		for {
 
-			for {
-
-				s.Wait()
+			s.Wait()
+			s.stopPlayTimer()
 
-				// Check is here because we don't want to seek back if we got paused
-				if !s.IsLooping {
-					break
-				}
+			if atomic.LoadInt64(&s.remainingLoops) == 0 {
+				break
+			}
 
-				s.SeekToPercent(0)
-				s.PlayAsync()
+			// Check is here because we don't want to seek back if we got paused
+			if !s.IsLooping || !s.waitLoopGap(gap) || !s.takeLoop() {
+				break
 			}
 
-		} else {
+			s.Rewind()
+			atomic.AddInt64(&s.currentLoop, 1)
+			beforePlay(iteration)
+			iteration++
+			s.Player.Play()
+			s.publishEvent(SoundEventType_Looped)
+		}
 
-			for timesToPlay > 0 {
+		// Pause clears IsLooping, so if we are still looping here then we ended naturally
+		if s.IsLooping && !s.IsClosed() {
+			s.publishEvent(SoundEventType_Finished)
+		}
 
-				timesToPlay--
-				s.Wait()
+		s.IsLooping = false
+		atomic.StoreInt64(&s.currentLoop, 0)
+	}()
+}
 
-				// Check is here because we don't want to seek back if we got paused
-				if !s.IsLooping {
-					break
-				}
+// takeLoop uses up one of the remaining loops, and returns false if there are none left
+func (s *Sound) takeLoop() bool {
 
-				s.SeekToPercent(0)
-				s.PlayAsync()
-			}
+	for {
+
+		remaining := atomic.LoadInt64(&s.remainingLoops)
+		if remaining == 0 {
+			return false
 		}
 
-		s.IsLooping = false
-	}()
+		if remaining < 0 || atomic.CompareAndSwapInt64(&s.remainingLoops, remaining, remaining-1) {
+			return true
+		}
+	}
+}
+
+// CurrentLoop returns which pass of the loop is playing, starting at 1. For example, it returns 3 while the
+// third of 5 passes of LoopAsync(5) plays. Zero is returned when the sound is not looping
+func (s *Sound) CurrentLoop() int {
+	return int(atomic.LoadInt64(&s.currentLoop))
+}
+
+// TotalLoops returns the number of passes the running loop will play, which takes SetRemainingLoops into account.
+// -1 is returned for infinite loops, and zero when the sound is not looping
+func (s *Sound) TotalLoops() int {
+
+	current := atomic.LoadInt64(&s.currentLoop)
+	if current == 0 {
+		return 0
+	}
+
+	remaining := atomic.LoadInt64(&s.remainingLoops)
+	if remaining < 0 {
+		return -1
+	}
+
+	return int(current + remaining)
+}
+
+// SetRemainingLoops changes how many more times a looping sound plays after the current pass.
+// n>=0 plays the sound n more times then stops, and n<0 makes it loop indefinitely until paused.
+// For example, SetRemainingLoops(2) on a sound started with LoopAsync(-1) plays it two more times.
+//
+// This only affects a loop that's running, and is picked up when the current pass ends
+func (s *Sound) SetRemainingLoops(n int) {
+
+	if n < 0 {
+		n = -1
+	}
+
+	atomic.StoreInt64(&s.remainingLoops, int64(n))
+}
+
+// waitLoopGap sleeps for the gap between loop iterations, and returns false if looping was stopped during the gap
+func (s *Sound) waitLoopGap(gap time.Duration) bool {
+
+	if gap <= 0 {
+		return true
+	}
+
+	clk.Sleep(gap)
+	return s.IsLooping
+}
+
+// StopAfterCurrentLoop ends looping without interrupting the current pass, so the sound stops
+// naturally when it reaches its end instead of cutting out like with Pause.
+// Does nothing if the sound is not looping
+func (s *Sound) StopAfterCurrentLoop() {
+	s.IsLooping = false
 }
 
 // TotalTime returns the time taken to play the entire sound.
@@ -195,20 +694,170 @@ func (s *Sound) RemainingTime() time.Duration {
 		return 0
 	}
 
+	remainingBytes := s.endBytePos() - s.currBytePos()
+	if remainingBytes < 0 {
+		return 0
+	}
+
+	return PlayTimeFromByteCount(remainingBytes)
+}
+
+// Errors returns a channel that receives errors (other than io.EOF) that happen while the sound is being read during playback,
+// for example a disk failure while streaming. Those errors would otherwise be invisible since reading happens in the background.
+//
+// The channel is buffered, and errors are dropped if it's full.
+// Sounds that can't have read errors (e.g. in-memory sounds) return a nil channel, which never receives
+func (s *Sound) Errors() <-chan error {
+	return s.readErrs
+}
+
+// Position returns how much of the sound has been played, which is affected by pausing/resetting/seeking of the sound.
+// Returns zero after close
+func (s *Sound) Position() time.Duration {
+
+	if s.IsClosed() {
+		return 0
+	}
+
+	return PlayTimeFromByteCount(s.currBytePos())
+}
+
+// TimeAtPercent returns the time at 'percent' of the sound's total time, which is useful to map a progress bar position to a time.
+// percent is clamped between [0,1]
+func (s *Sound) TimeAtPercent(percent float64) time.Duration {
+	return time.Duration(float64(s.TotalTime()) * clamp01F64(percent))
+}
+
+// PercentAtTime returns how far into the sound 't' is as a value between [0,1], which is useful to show a time on a progress bar.
+// t is clamped between [0, totalTime], and zero is returned for sounds with no length
+func (s *Sound) PercentAtTime(t time.Duration) float64 {
+
+	totalTime := s.TotalTime()
+	if totalTime <= 0 {
+		return 0
+	}
+
+	return clamp01F64(float64(t) / float64(totalTime))
+}
+
+// endBytePos returns the position at which playing stops, which is the end of the playback range if one is set
+func (s *Sound) endBytePos() int64 {
+
+	if rr, ok := s.Data.(*rangeReader); ok {
+		return rr.To
+	}
+
+	return s.Info.Size
+}
+
+// SetPlaybackRange limits a streaming sound to only play between 'from' and 'to', without loading anything into memory.
+// The sound is moved to 'from', and reaching 'to' ends the sound just like reaching the end of the file would.
+// Seeks and loops stay within the range, so for example SeekToPercent(0) goes to 'from' and LoopAsync repeats only the range.
+//
+// from and to are clamped to [0, totalTime]. Using from=0 and to=0 clears the range so the full sound is played again.
+//
+// Returns ErrSoundClosed if the sound is closed, or any error from seeking the source.
+//
+// Panics if the sound is not streaming (use ClipInMemSoundPercent for in-memory sounds), or if to<=from after clamping
+func (s *Sound) SetPlaybackRange(from, to time.Duration) error {
+
+	if s.Info.Mode != SoundMode_Streaming {
+		panic("only streaming sounds can have a playback range. Please use ClipInMemSoundPercent for in-memory sounds")
+	}
+
+	return s.setStreamingRange(from, to, false)
+}
+
+// SetLoopRange is like SetPlaybackRange, but instead of ending at 'to' the sound seamlessly continues from 'from',
+// so once played it keeps repeating the range until paused (A-B looping).
+// Using from=0 and to=0 clears the range. SetPlaybackRange can also be used to replace or clear it.
+//
+// Jumping back to 'from' happens inside the read that reaches 'to', so there is no gap between repeats,
+// but it costs a seek of the underlying decoder every repeat. Seeking WAV is cheap, while MP3 and OGG
+// seeks have to find and decode from a frame/page boundary, which can take a few milliseconds for OGG.
+// Very short ranges therefore mean frequent seeks, and if a seek is slower than the player's buffer the
+// result is an audible stutter. Ranges of at least a few hundred milliseconds avoid that.
+//
+// While looping RemainingTime only reports the time till the end of the current repeat.
+//
+// Returns errors like SetPlaybackRange.
+//
+// Panics if the sound is not streaming, or if to<=from after clamping
+func (s *Sound) SetLoopRange(from, to time.Duration) error {
+
+	if s.Info.Mode != SoundMode_Streaming {
+		panic("only streaming sounds can have a loop range")
+	}
+
+	return s.setStreamingRange(from, to, true)
+}
+
+// setStreamingRange is the shared implementation of SetPlaybackRange and SetLoopRange.
+// Any existing range is replaced rather than nested, and the range is always the outermost reader (see Prebuffer)
+func (s *Sound) setStreamingRange(from, to time.Duration, loop bool) error {
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	src := s.Data
+	if rr, ok := src.(*rangeReader); ok {
+		src = rr.Src
+	}
+
+	if from == 0 && to == 0 {
+		return s.replaceData(src)
+	}
+
+	fromByte := clampByteCount(alignToSample(ByteCountFromPlayTime(from)), s.Info.Size)
+	toByte := clampByteCount(alignToSample(ByteCountFromPlayTime(to)), s.Info.Size)
+	if toByte <= fromByte {
+		panic("playback range 'to' must be bigger than 'from'")
+	}
+
+	if _, err := src.Seek(fromByte, io.SeekStart); err != nil {
+		return err
+	}
+
+	return s.replaceData(&rangeReader{
+		Src:  src,
+		From: fromByte,
+		To:   toByte,
+		Pos:  fromByte,
+		Loop: loop,
+	})
+}
+
+// currBytePos returns the position of the play head, which is the position of Data minus whatever
+// the player has read but not yet played
+func (s *Sound) currBytePos() int64 {
+
 	currBytePos, _ := s.Data.Seek(0, io.SeekCurrent)
 	currBytePos -= int64(s.Player.UnplayedBufferSize())
 
-	return PlayTimeFromByteCount(s.Info.Size - currBytePos)
+	// With a loop range the unplayed data might have wrapped around, so the play head is before the end of the range
+	if rr, ok := s.Data.(*rangeReader); ok && rr.Loop && rr.To > rr.From {
+		for currBytePos < rr.From {
+			currBytePos += rr.To - rr.From
+		}
+	}
+
+	if currBytePos < 0 {
+		return 0
+	}
+
+	return currBytePos
 }
 
 // SetVolume must be between 0 and 1 (both inclusive). Other values will panic.
-// The default volume is 1.
+// The default volume is 1. Any running fade is cancelled.
 func (s *Sound) SetVolume(newVol float64) {
 
 	if newVol < 0 || newVol > 1 {
 		panic("sound volume can not be less than zero or bigger than one")
 	}
 
+	s.cancelFade()
 	s.Player.SetVolume(newVol)
 }
 
@@ -217,9 +866,52 @@ func (s *Sound) Volume() float64 {
 	return s.Player.Volume()
 }
 
+// Pause pauses the sound and ends any loop.
+// The OnPause hook (see SetOnPause) runs after the sound is paused
 func (s *Sound) Pause() {
 	s.IsLooping = false
 	s.Player.Pause()
+	s.publishEvent(SoundEventType_Paused)
+	s.callOnPause()
+}
+
+// Stop pauses the sound and rewinds it, so the next play starts from the beginning
+func (s *Sound) Stop() {
+	s.Pause()
+	s.Rewind()
+}
+
+// Reset returns the sound to the state it had right after loading, ready to be played from the start.
+// It stops the sound if it's playing, ends any loop, cancels any running fade, discards unplayed audio buffered by the player, and rewinds.
+// Settings that are part of the sound rather than its playback state, like volume, playback range and effects applied to the data, are kept.
+//
+// This is the recommended way to prepare a sound for replaying, for example after it played to its end.
+// It is a no-op after close
+func (s *Sound) Reset() {
+
+	if s.IsClosed() {
+		return
+	}
+
+	if s.IsPlaying() {
+		s.Pause()
+	}
+
+	s.IsLooping = false
+	atomic.StoreInt64(&s.remainingLoops, 0)
+	atomic.StoreInt64(&s.currentLoop, 0)
+	s.cancelFade()
+
+	// Seeking the player discards its unplayed audio, so Rewind is all that's needed (Player.Reset is deprecated)
+	s.Rewind()
+}
+
+// Rewind moves the sound back to its start, which is the same as SeekToPercent(0).
+// Unplayed audio buffered by the player is discarded.
+//
+// This can be used while the sound is playing
+func (s *Sound) Rewind() {
+	s.PlayerSeeker.Seek(0, io.SeekStart)
 }
 
 func (s *Sound) IsPlaying() bool {
@@ -231,11 +923,18 @@ func (s *Sound) IsPlaying() bool {
 //
 // This can be used while the sound is playing.
 //
-// percent is clamped [0,1], so passing <0 is the same as zero, and >1 is the same as 1
+// percent is clamped [0,1], so passing <0 is the same as zero, and >1 is the same as 1.
+//
+// See SetBackwardSeekPolicy for backward seeks on sounds that can't do them cheaply, and SeekToPercentErr for a version that returns errors
 func (s *Sound) SeekToPercent(percent float64) {
+	s.SeekToPercentErr(percent)
+}
 
+// SeekToPercentErr is like SeekToPercent but returns ErrSoundClosed if the sound is closed,
+// ErrExpensiveBackwardSeek if the seek isn't allowed by the backward seek policy, or any error from the source
+func (s *Sound) SeekToPercentErr(percent float64) error {
 	percent = clamp01F64(percent)
-	s.PlayerSeeker.Seek(int64(float64(s.Info.Size)*percent), io.SeekStart)
+	return s.seekToByte(int64(float64(s.Info.Size) * percent))
 }
 
 // SeekToTime moves the current position of the sound to the given duration.
@@ -243,39 +942,267 @@ func (s *Sound) SeekToPercent(percent float64) {
 //
 // This can be used while the sound is playing.
 //
-// t is clamped between [0, totalTime]
+// t <0 is the same as zero, and t >totalTime is either clamped to totalTime or rejected depending on SetSeekOvershootPolicy.
+//
+// See SetBackwardSeekPolicy for backward seeks on sounds that can't do them cheaply, and SeekToTimeErr for a version that returns errors
 func (s *Sound) SeekToTime(t time.Duration) {
+	s.SeekToTimeErr(t)
+}
+
+// SeekToTimeErr is like SeekToTime but returns ErrSoundClosed if the sound is closed, ErrSeekPastEnd if t is past the end
+// and the overshoot policy is SeekOvershootPolicy_Error, ErrExpensiveBackwardSeek if the seek isn't allowed by the backward seek policy,
+// or any error from the source
+func (s *Sound) SeekToTimeErr(t time.Duration) error {
 
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	// The player seeks the source, so the source decides where the end is. This can differ from Info.Size,
+	// for example if the file is still growing or its header reported the wrong length
 	byteCount := ByteCountFromPlayTime(t)
-	if byteCount < 0 {
-		byteCount = 0
-	} else if byteCount > s.Info.Size {
-		byteCount = s.Info.Size
+	size := s.Data.Size()
+	if byteCount > size && seekOvershootPolicy == SeekOvershootPolicy_Error {
+		return ErrSeekPastEnd
+	}
+
+	return s.seekToByte(clampByteCount(byteCount, size))
+}
+
+// seekToByte moves the sound to bytePos after aligning it to a sample, while applying the backward seek policy
+func (s *Sound) seekToByte(bytePos int64) error {
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	// Seeking to a byte that isn't the start of a sample would mix up channels and sample bytes
+	bytePos = alignToSample(bytePos)
+	if zeroCrossingWindow > 0 && s.Info.Mode == SoundMode_Memory {
+		bytePos = snapToZeroCrossing(s.Data.(*SoundBuffer).Data, bytePos, ByteCountFromPlayTime(zeroCrossingWindow))
+	}
+
+	if backwardSeekPolicy == BackwardSeekPolicy_Error && !s.CanSeekBackward() && bytePos < s.currBytePos() {
+		return ErrExpensiveBackwardSeek
 	}
 
-	s.PlayerSeeker.Seek(byteCount, io.SeekStart)
+	if seekFadeDuration > 0 && s.IsPlaying() {
+		return s.seekWithFade(bytePos, seekFadeDuration)
+	}
+
+	_, err := s.PlayerSeeker.Seek(bytePos, io.SeekStart)
+	return err
+}
+
+// CanSeekBackward reports whether the sound can seek backward cheaply, which is true for in-memory and WAV sounds
+// but false for streaming OGG sounds. Returns false after close.
+//
+// Backward seeks on sounds that can't do them cheaply still work, but can be slow. See SetBackwardSeekPolicy
+func (s *Sound) CanSeekBackward() bool {
+	return !s.IsClosed() && s.Data.CanSeekBackCheaply()
+}
+
+// Prebuffer reads 'd' worth of audio of a streaming sound into memory, starting from the current position,
+// so that the start of the next play doesn't have to wait on a cold file/decoder.
+//
+// The buffered audio is only used if playback continues from the current position, so any seek (including looping) discards it.
+// For in-memory sounds this is a no-op.
+//
+// An error is returned if the sound is closed or is currently playing
+func (s *Sound) Prebuffer(d time.Duration) error {
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	if s.Info.Mode != SoundMode_Streaming {
+		return nil
+	}
+
+	if s.IsPlaying() {
+		return ErrSoundPlaying
+	}
+
+	// The player might hold unplayed bytes from before a pause, so we move the source to the actual
+	// play position before reading from it
+	_, err := s.PlayerSeeker.Seek(s.currBytePos(), io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	// A playback range must stay the outermost reader so that the play head and end are in its terms,
+	// so with a range we prebuffer the source inside it
+	rr, hasRange := s.Data.(*rangeReader)
+	src := s.Data
+	if hasRange {
+		src = rr.Src
+	}
+
+	if pr, ok := src.(*prebufferedReader); ok {
+		src = pr.Src
+	}
+
+	pr, err := newPrebufferedReader(src, ByteCountFromPlayTime(d))
+	if err != nil {
+		return err
+	}
+
+	if hasRange {
+		return s.replaceData(&rangeReader{
+			Src:  pr,
+			From: rr.From,
+			To:   rr.To,
+			Pos:  rr.Pos,
+			Loop: rr.Loop,
+		})
+	}
+
+	return s.replaceData(pr)
+}
+
+// replaceData closes the current player and creates a new one that reads from newData starting at its current position.
+// The volume and playing state of the old player are kept
+func (s *Sound) replaceData(newData Source) error {
+
+	vol := s.Player.Volume()
+	wasPlaying := s.Player.IsPlaying()
+	if err := s.Player.Close(); err != nil {
+		return err
+	}
+
+	if oldSb, ok := s.Data.(*SoundBuffer); ok && s.Info.Mode == SoundMode_Memory {
+		if newSb, ok := newData.(*SoundBuffer); ok {
+			updateMemSound(s, oldSb.Data, newSb.Data)
+		}
+	}
+
+	s.Data = newData
+	s.Player = newPlayer(s.playerSource(newData))
+	s.PlayerSeeker = s.Player.(io.Seeker)
+	s.Player.SetVolume(vol)
+	if wasPlaying {
+		s.Player.Play()
+	}
+
+	return nil
+}
+
+// SetData replaces the data of an in-memory sound with sb, which is useful after processing a copy of the data
+// (e.g. with a filter). The old player is closed and a new one is created over sb, Info.Size is updated, and volume and playing state are kept.
+// Playback continues from sb.Pos.
+//
+// Panics if the sound is not in-memory
+func (s *Sound) SetData(sb *SoundBuffer) error {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can have their data set")
+	}
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	if err := s.replaceData(sb); err != nil {
+		return err
+	}
+
+	s.Info.Size = sb.Len()
+	return nil
+}
+
+// SeekBy moves the current position of the sound by d relative to the current play position,
+// so for example d=10*time.Second skips forward 10 seconds while d=-10*time.Second goes back 10 seconds.
+//
+// This can be used while the sound is playing.
+//
+// The new position is clamped between [0, totalTime]
+func (s *Sound) SeekBy(d time.Duration) {
+	s.SeekByErr(d)
+}
+
+// SeekByErr is like SeekBy but returns errors like SeekToTimeErr
+func (s *Sound) SeekByErr(d time.Duration) error {
+
+	if s.IsClosed() {
+		return ErrSoundClosed
+	}
+
+	newPos := s.Position() + d
+	if newPos < 0 {
+		newPos = 0
+	} else if end := PlayTimeFromByteCount(s.Data.Size()); newPos > end {
+		newPos = end
+	}
+
+	return s.SeekToTimeErr(newPos)
 }
 
 func (s *Sound) IsClosed() bool {
 	return s.Data == nil
 }
 
+// AtEnd returns true if the sound has nothing more to play from its current position, because its data reached the end
+// (e.g. io.EOF while streaming) and the player has played everything it read. This is different from being paused, and is useful for
+// auto-advancing only when a sound truly ended. It works for sounds without a known length too, like a closed StreamSink.
+//
+// A sound with a loop range never reaches its end, and seeking back makes AtEnd false again. Returns false after close
+func (s *Sound) AtEnd() bool {
+
+	if s.IsClosed() {
+		return false
+	}
+
+	return s.Data.AtEOF() && s.Player.UnplayedBufferSize() == 0
+}
+
 // Close will clean underlying resources, and the 'Ctx' and 'Bytes' fields will be made nil.
-// Repeated calls are no-ops
+// Repeated calls are no-ops.
+//
+// A sound that is playing or looping is stopped before its resources are closed.
+//
+// Close satisfies io.Closer, so a sound can be used with 'defer s.Close()' and in code that works on io.Closer values
 func (s *Sound) Close() error {
 
 	if s.IsClosed() {
 		return nil
 	}
 
+	// Playback is stopped first so the player isn't reading while things are closed under it, and so that
+	// loop and fade goroutines stop on their next check instead of replaying or touching the closed player
+	s.IsLooping = false
+	atomic.StoreInt64(&s.remainingLoops, 0)
+	s.cancelFade()
+	s.Player.Pause()
+
 	var fdErr error = nil
 	if s.File != nil {
 		fdErr = s.File.Close()
 	}
 
+	// Unblock any writers waiting on the sink
+	if sink, ok := s.Data.(*StreamSink); ok {
+		sink.Close()
+	}
+
+	if s.Info.Mode == SoundMode_Memory {
+		unregisterMemSound(s)
+	}
+	groupsLock.Lock()
+	s.setGroup("", false)
+	groupsLock.Unlock()
+
 	s.Data = nil
 	playerErr := s.Player.Close()
 
+	// The player must be closed before unmapping, otherwise it might read unmapped memory
+	if s.unmap != nil {
+
+		if err := s.unmap(); err != nil && fdErr == nil {
+			fdErr = err
+		}
+		s.unmap = nil
+	}
+
 	if playerErr == nil && fdErr == nil {
 		return nil
 	}
@@ -284,39 +1211,125 @@ func (s *Sound) Close() error {
 		return fmt.Errorf("closingFileErr: %s; underlyingPlayerErr: %s", fdErr.Error(), playerErr.Error())
 	}
 
-	if playerErr != nil {
-		return playerErr
+	if playerErr != nil {
+		return playerErr
+	}
+
+	return fdErr
+}
+
+// String returns a short summary of the sound, for example: 'Sound{Type: MP3, Mode: Memory, Size: 9784320 bytes, Duration: 55.484s}'.
+// Safe to use after close
+func (s *Sound) String() string {
+	return fmt.Sprintf("Sound{Type: %s, Mode: %s, Size: %d bytes, Duration: %s}", s.Info.Type, s.Info.Mode, s.Info.Size, s.TotalTime())
+}
+
+// SourcePath returns the path of the file the sound was loaded from (e.g. the path passed to NewSoundMem).
+// Copies of a sound return the path of the original, and sounds not loaded from a file (e.g. NewSoundStreamSink) return an empty string.
+// Safe to use after close
+func (s *Sound) SourcePath() string {
+	return s.fpath
+}
+
+// WriteTo writes the PCM of the sound from the current position to the end (or the end of the playback range) to w, without using the audio device.
+// The data is in the format wavy was initialized with. Streaming sounds are decoded while writing.
+// This can be used for transcoding, recording what a sound would play, or in tests.
+//
+// Like playing, this moves the sound to its end. Sounds with no fixed length (e.g. NewSoundStreamSink) write nothing.
+//
+// ErrSoundClosed is returned if the sound is closed, and ErrSoundPlaying if it's playing
+func (s *Sound) WriteTo(w io.Writer) (int64, error) {
+
+	if s.IsClosed() {
+		return 0, ErrSoundClosed
+	}
+
+	if s.IsPlaying() {
+		return 0, ErrSoundPlaying
+	}
+
+	// The player might hold unplayed bytes, so we move the source to the actual play position before reading from it
+	startPos := s.currBytePos()
+	if _, err := s.PlayerSeeker.Seek(startPos, io.SeekStart); err != nil {
+		return 0, err
 	}
 
-	return fdErr
+	// Limiting is needed for loop ranges, which never reach io.EOF
+	return io.Copy(w, io.LimitReader(s.Data, s.endBytePos()-startPos))
 }
 
 // CopyInMemSound returns a new sound object that has identitcal info and uses the same underlying data, but with independent play controls (e.g. one playing at the start while one is in the middle).
 // Since the sound data is not copied this function is very fast.
 //
-// Panics if the sound is not in-memory
+// Each copy has its own player, and creating players isn't free, so for rapid-fire sound effects it's cheaper to keep
+// a few copies around and reuse finished ones with Reset than to make a new copy per play.
+//
+// The copy starts with the same effect state as s (e.g. volume), so a copied sound effect sounds like its source.
+// Effects that change the data itself, like SetStereoWidth, are carried over because the data is shared.
+//
+// Panics if the sound is not in-memory. CopyInMemSoundErr returns an error instead
 func CopyInMemSound(s *Sound) *Sound {
 
 	if s.Info.Mode != SoundMode_Memory {
 		panic("only in-memory sounds can be copied. Please use NewSoundStreaming if you want to have multiple sound objects of a streaming sound")
 	}
 
+	return copyInMemSoundWithData(s, s.Data.(*SoundBuffer).Copy())
+}
+
+// ForkAtCurrent is like CopyInMemSound, but the new sound starts at the current play position of s instead of at the start.
+// This is useful for branching audio, for example to continue a sound with different effects from where it is now.
+//
+// Panics if the sound is not in-memory
+func ForkAtCurrent(s *Sound) *Sound {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be forked. Please use NewSoundStreaming then SeekToTime to get a streaming sound at the same position")
+	}
+
 	sb := s.Data.(*SoundBuffer).Copy()
+	sb.Pos = s.currBytePos()
+
+	return copyInMemSoundWithData(s, sb)
+}
 
-	p := Ctx.NewPlayer(sb)
-	p.SetVolume(s.Volume())
+// CopyInMemSoundErr is like CopyInMemSound, but returns ErrNotInMemory instead of panicking if the sound is not in-memory,
+// and ErrSoundClosed if it's closed
+func CopyInMemSoundErr(s *Sound) (*Sound, error) {
 
-	return &Sound{
+	if s.Info.Mode != SoundMode_Memory {
+		return nil, ErrNotInMemory
+	}
+
+	if s.IsClosed() {
+		return nil, ErrSoundClosed
+	}
+
+	return CopyInMemSound(s), nil
+}
+
+// copyInMemSoundWithData creates a sound with the info and effect state of s that plays from sb
+func copyInMemSoundWithData(s *Sound, sb *SoundBuffer) *Sound {
+
+	p := newPlayer(sb)
+	newSound := &Sound{
 		Player:       p,
 		PlayerSeeker: p.(io.Seeker),
 		File:         nil,
 		Data:         sb,
 		Info:         s.Info,
+		fpath:        s.fpath,
 	}
+
+	s.effects().applyTo(newSound)
+	registerMemSoundCopy(newSound, s)
+	return newSound
 }
 
 // ClipInMemSoundPercent is like CopyInMemSound but produces a sound that plays only between from and to.
-// fromPercent and toPercent must be between 0 and 1
+// fromPercent and toPercent must be between 0 and 1.
+//
+// Panics if the sound is not in-memory. ClipInMemSoundPercentErr returns an error instead
 func ClipInMemSoundPercent(s *Sound, fromPercent, toPercent float64) *Sound {
 
 	if s.Info.Mode != SoundMode_Memory {
@@ -328,98 +1341,236 @@ func ClipInMemSoundPercent(s *Sound, fromPercent, toPercent float64) *Sound {
 
 	sb := s.Data.(*SoundBuffer).Copy()
 
-	start := int64(float64(len(sb.Data)) * fromPercent)
-	end := int64(float64(len(sb.Data)) * toPercent)
+	start := int64(float64(sb.Len()) * fromPercent)
+	end := int64(float64(sb.Len()) * toPercent)
 	sb.Data = sb.Data[start:end]
 
-	p := Ctx.NewPlayer(sb)
-	p.SetVolume(s.Volume())
+	return copyInMemSoundWithData(s, sb)
+}
 
-	return &Sound{
-		Player:       p,
-		PlayerSeeker: p.(io.Seeker),
-		File:         nil,
-		Data:         sb,
-		Info:         s.Info,
+// ClipInMemSoundPercentErr is like ClipInMemSoundPercent, but returns ErrNotInMemory instead of panicking if the sound is not in-memory,
+// and ErrSoundClosed if it's closed
+func ClipInMemSoundPercentErr(s *Sound, fromPercent, toPercent float64) (*Sound, error) {
+
+	if s.Info.Mode != SoundMode_Memory {
+		return nil, ErrNotInMemory
+	}
+
+	if s.IsClosed() {
+		return nil, ErrSoundClosed
+	}
+
+	return ClipInMemSoundPercent(s, fromPercent, toPercent), nil
+}
+
+// Split cuts s into n in-memory sounds that play one after the other, for example to chop a drum loop into slices.
+// Like ClipInMemSoundPercent the data is shared and not copied. The slices are frame aligned and together cover the whole sound,
+// so if the frame count isn't divisible by n some slices are one frame longer than others.
+//
+// Panics if the sound is not in-memory, or if n<=0
+func Split(s *Sound, n int) []*Sound {
+
+	if s.Info.Mode != SoundMode_Memory {
+		panic("only in-memory sounds can be used in Split")
+	}
+
+	if n <= 0 {
+		panic("sound can only be split into one or more parts")
+	}
+
+	data := s.Data.(*SoundBuffer).Data
+	frameCount := int64(len(data)) / BytesPerSample
+
+	parts := make([]*Sound, n)
+	for i := 0; i < n; i++ {
+
+		start := frameCount * int64(i) / int64(n) * BytesPerSample
+		end := frameCount * int64(i+1) / int64(n) * BytesPerSample
+
+		parts[i] = copyInMemSoundWithData(s, &SoundBuffer{Data: data[start:end]})
+		parts[i].Info.Size = end - start
 	}
+
+	return parts
 }
 
 func PauseAllSounds() {
+	ctxLock.Lock()
+	defer ctxLock.Unlock()
 	Ctx.Suspend()
+	ctxSuspended = true
 }
 
 func ResumeAllSounds() {
+	ctxLock.Lock()
+	defer ctxLock.Unlock()
 	Ctx.Resume()
+	ctxSuspended = false
+}
+
+// PlayTogether plays all the sounds as close together as possible, which helps keep things like the stems of a song in sync.
+// Each sound starts from its current position, as with PlayAsync.
+//
+// Starting a player fills its first buffer, which takes time (especially for streaming sounds), so sounds played one after the other
+// with PlayAsync can start a buffer or more apart. To reduce that the context is suspended while the sounds are started, and is only
+// resumed once every player has filled its buffer (or after playerStartTimeout), so any other playing sounds are held meanwhile.
+// If the context was already suspended by PauseAllSounds then it stays suspended, and the sounds start once ResumeAllSounds is called.
+//
+// This is best effort and doesn't guarantee the sounds start on the same output sample, because the audio backend adds a started player
+// to its mix slightly after the player reports playing. Sounds also only stay in sync if they play at the context rate, which they do
+// unless they were loaded with a mismatched rate (see SetRateMismatchPolicy). Their OnPlay hooks run while the other sounds are held, so they should be fast
+func PlayTogether(sounds ...*Sound) {
+
+	if len(sounds) == 0 {
+		return
+	}
+
+	ctxLock.Lock()
+	wasSuspended := ctxSuspended
+	if !wasSuspended {
+		Ctx.Suspend()
+	}
+	ctxLock.Unlock()
+
+	for _, s := range sounds {
+		s.PlayAsync()
+	}
+
+	// Players start in the background, so we wait till they have filled their buffers before letting the context mix them
+	waitPlayersStarted(sounds, playerStartTimeout)
+
+	// If PauseAllSounds was called meanwhile then the context must stay suspended
+	ctxLock.Lock()
+	if !wasSuspended && !ctxSuspended {
+		Ctx.Resume()
+	}
+	ctxLock.Unlock()
+}
+
+// waitPlayersStarted waits until the players of all sounds report playing, or up to timeout.
+// Sounds that have nothing to play (e.g. they are at their end) never start playing, so they aren't waited on
+func waitPlayersStarted(sounds []*Sound, timeout time.Duration) {
+
+	for waited := time.Duration(0); waited < timeout; waited += time.Millisecond {
+
+		allStarted := true
+		for _, s := range sounds {
+			if !s.IsClosed() && !s.Player.IsPlaying() && !s.Data.AtEOF() {
+				allStarted = false
+				break
+			}
+		}
+
+		if allStarted {
+			return
+		}
+
+		clk.Sleep(time.Millisecond)
+	}
+}
+
+// newPlayer creates a player on Ctx, and is safe to use from multiple goroutines (e.g. when loading sounds in parallel)
+func newPlayer(r io.Reader) oto.Player {
+	ctxLock.Lock()
+	defer ctxLock.Unlock()
+	return Ctx.NewPlayer(r)
 }
 
 // NewSoundStreaming plays sound by streaming from a file, so no need to load the entire file into memory.
-// Good for large sound files
+// Good for large sound files.
+// Loading failures are returned as a *LoadError
 func NewSoundStreaming(fpath string) (s *Sound, err error) {
 
 	soundType := GetSoundFileType(fpath)
 	if soundType == SoundType_Unknown {
-		return nil, errUnknownSoundType
+		return nil, newLoadError(fpath, soundType, errUnknownSoundType)
+	}
+
+	if !IsSoundTypeSupported(soundType) {
+		return nil, newLoadError(fpath, soundType, ErrUnsupportedSoundType)
 	}
 
 	// We read file but don't close so the player can stream the file any time later
 	file, err := os.Open(fpath)
 	if err != nil {
-		return nil, err
+		return nil, newLoadError(fpath, soundType, err)
 	}
 
 	s = &Sound{
-		File: file,
+		File:  file,
+		fpath: fpath,
 		Info: SoundInfo{
 			Type: soundType,
 			Mode: SoundMode_Streaming,
 		},
 	}
 
-	err = soundFromFile(file, s)
+	err = soundFromReadSeeker(file, s)
 	if err != nil {
-		return nil, getLoadingErr(fpath, err)
+		file.Close()
+		return nil, newLoadError(fpath, soundType, err)
+	}
+
+	if err := checkSampleRate(s); err != nil {
+		s.Close()
+		return nil, newLoadError(fpath, soundType, err)
 	}
 
 	return s, nil
 }
 
-func soundFromFile(f *os.File, s *Sound) error {
+// soundFromReadSeeker sets up s to stream by decoding r on the fly. s.File is given to the streamers
+// so it stays alive, and can be nil if r isn't a file (e.g. a memory mapped file)
+func soundFromReadSeeker(r io.ReadSeeker, s *Sound) error {
 
 	if s.Info.Type == SoundType_MP3 {
 
-		dec, err := mp3.NewDecoder(f)
+		mp3Src, err := skipMp3Tags(r)
+		if err != nil {
+			return err
+		}
+
+		dec, err := mp3.NewDecoder(mp3Src)
 		if err != nil {
 			return err
 		}
 
-		s.Data = dec
-		s.Player = Ctx.NewPlayer(dec)
+		mp3Streamer := NewMp3Streamer(s.File, dec)
+
+		s.Data = mp3Streamer
+		s.readErrs = mp3Streamer.Errors()
+		s.Player = newPlayer(mp3Streamer)
 		s.PlayerSeeker = s.Player.(io.Seeker)
-		s.Info.Size = dec.Length()
+		s.Info.Size = mp3Streamer.Size()
+		s.Info.NativeSampleRate = SampleRate(dec.SampleRate())
 	} else if s.Info.Type == SoundType_WAV {
 
-		ws, err := NewWavStreamer(f, wav.NewDecoder(f))
+		ws, err := NewWavStreamer(s.File, wav.NewDecoder(r))
 		if err != nil {
 			return err
 		}
 
 		s.Data = ws
-		s.Player = Ctx.NewPlayer(ws)
+		s.readErrs = ws.Errors()
+		s.Player = newPlayer(ws)
 		s.PlayerSeeker = s.Player.(io.Seeker)
 		s.Info.Size = ws.Size()
+		s.Info.NativeSampleRate = SampleRate(ws.Dec.SampleRate)
 	} else if s.Info.Type == SoundType_OGG {
 
-		oggReader, err := oggvorbis.NewReader(f)
+		oggReader, err := oggvorbis.NewReader(r)
 		if err != nil {
 			return err
 		}
 
-		oggStreamer := NewOggStreamer(f, oggReader)
+		oggStreamer := NewOggStreamer(s.File, oggReader)
 
 		s.Data = oggStreamer
-		s.Player = Ctx.NewPlayer(oggStreamer)
+		s.readErrs = oggStreamer.Errors()
+		s.Player = newPlayer(oggStreamer)
 		s.PlayerSeeker = s.Player.(io.Seeker)
 		s.Info.Size = oggStreamer.Size()
+		s.Info.NativeSampleRate = SampleRate(oggReader.SampleRate())
 	}
 
 	if s.Data == nil {
@@ -429,21 +1580,36 @@ func soundFromFile(f *os.File, s *Sound) error {
 	return nil
 }
 
-// NewSoundMem loads the entire sound file into memory
+// NewSoundMem loads the entire sound file into memory.
+// Loading failures are returned as a *LoadError
 func NewSoundMem(fpath string) (s *Sound, err error) {
 
 	soundType := GetSoundFileType(fpath)
 	if soundType == SoundType_Unknown {
-		return nil, getLoadingErr(fpath, errUnknownSoundType)
+		return nil, newLoadError(fpath, soundType, errUnknownSoundType)
+	}
+
+	if !IsSoundTypeSupported(soundType) {
+		return nil, newLoadError(fpath, soundType, ErrUnsupportedSoundType)
+	}
+
+	fileInfo, err := os.Stat(fpath)
+	if err != nil {
+		return nil, newLoadError(fpath, soundType, err)
+	}
+
+	if err := checkInMemorySize(fileInfo.Size()); err != nil {
+		return nil, newLoadError(fpath, soundType, err)
 	}
 
 	fileBytes, err := os.ReadFile(fpath)
 	if err != nil {
-		return nil, getLoadingErr(fpath, err)
+		return nil, newLoadError(fpath, soundType, err)
 	}
 
 	bytesReader := bytes.NewReader(fileBytes)
 	s = &Sound{
+		fpath: fpath,
 		Info: SoundInfo{
 			Type: soundType,
 			Mode: SoundMode_Memory,
@@ -451,38 +1617,81 @@ func NewSoundMem(fpath string) (s *Sound, err error) {
 	}
 
 	err = decodeSoundFromReaderSeeker(bytesReader, s)
+	if s.Player != nil {
+		if rateErr := checkSampleRate(s); rateErr != nil {
+			s.Close()
+			return nil, newLoadError(fpath, soundType, rateErr)
+		}
+	}
+
 	if err != nil {
-		return nil, getLoadingErr(fpath, err)
+
+		var partialErr *PartialDecodeError
+		if errors.As(err, &partialErr) {
+			return s, newLoadError(fpath, soundType, err)
+		}
+
+		return nil, newLoadError(fpath, soundType, err)
 	}
 
 	return s, nil
 }
 
-func getLoadingErr(fpath string, err error) error {
-	return fmt.Errorf("failed to load '%s' with err '%s'", fpath, err.Error())
+func newLoadError(fpath string, soundType SoundType, err error) *LoadError {
+	return &LoadError{
+		Path: fpath,
+		Type: soundType,
+		Err:  err,
+	}
 }
 
 // decodeSoundFromReaderSeeker reads and decodes till EOF, and places the final
-// PCM16 data in a buffer, thus producing an in-memory sound
+// PCM16 data in a buffer, thus producing an in-memory sound.
+//
+// If partial decoding is allowed and decoding fails after some data was decoded, the sound is still produced
+// and a *PartialDecodeError is returned
 func decodeSoundFromReaderSeeker(r io.ReadSeeker, s *Sound) error {
 
+	var decodeErr error
+
 	if s.Info.Type == SoundType_MP3 {
 
-		dec, err := mp3.NewDecoder(r)
+		mp3Src, err := skipMp3Tags(r)
 		if err != nil {
 			return err
 		}
 
-		finalBuf, err := ReadAllFromReader(dec, 0, uint64(dec.Length()))
+		dec, err := mp3.NewDecoder(mp3Src)
 		if err != nil {
 			return err
 		}
 
+		var pcmSrc io.Reader = dec
+		pcmSize := dec.Length()
+		if resampler := newMp3Resampler(dec); resampler != nil {
+			pcmSrc = resampler
+			pcmSize = resampler.Size()
+		}
+
+		if err := checkInMemorySize(pcmSize); err != nil {
+			return err
+		}
+
+		finalBuf, err := ReadAllFromReader(pcmSrc, 0, uint64(pcmSize))
+		if err != nil {
+
+			decodeErr = partialDecodeErr(err, len(finalBuf))
+			if decodeErr == nil {
+				return err
+			}
+		}
+
 		sb := &SoundBuffer{Data: finalBuf}
 		s.Data = sb
-		s.Player = Ctx.NewPlayer(sb)
+		s.Player = newPlayer(sb)
 		s.PlayerSeeker = s.Player.(io.Seeker)
-		s.Info.Size = int64(len(sb.Data))
+		s.Info.Size = sb.Len()
+		s.Info.NativeSampleRate = SampleRate(dec.SampleRate())
 	} else if s.Info.Type == SoundType_WAV {
 
 		wavDec := wav.NewDecoder(r)
@@ -491,59 +1700,157 @@ func decodeSoundFromReaderSeeker(r io.ReadSeeker, s *Sound) error {
 			return err
 		}
 
+		if err := checkInMemorySize(int64(wavDec.PCMSize)); err != nil {
+			return err
+		}
+
 		finalBuf, err := ReadAllFromReader(wavDec.PCMChunk, 0, uint64(wavDec.PCMSize))
 		if err != nil {
-			return err
+
+			decodeErr = partialDecodeErr(err, len(finalBuf))
+			if decodeErr == nil {
+				return err
+			}
 		}
 
-		sb := &SoundBuffer{Data: finalBuf}
+		// Size must come from the converted buffer, otherwise the time functions are wrong for files that don't match the context channel count
+		sb := &SoundBuffer{Data: convertChannels(finalBuf, int(wavDec.NumChans), int(ChanCount))}
 		s.Data = sb
-		s.Player = Ctx.NewPlayer(sb)
+		s.Player = newPlayer(sb)
 		s.PlayerSeeker = s.Player.(io.Seeker)
-		s.Info.Size = int64(len(sb.Data))
+		s.Info.Size = sb.Len()
+		s.Info.NativeSampleRate = SampleRate(wavDec.SampleRate)
 	} else if s.Info.Type == SoundType_OGG {
 
-		soundData, _, err := oggvorbis.ReadAll(r)
+		soundData, format, err := readAllOggPCM16(r)
 		if err != nil {
-			return err
+
+			if format == nil {
+				return err
+			}
+
+			decodeErr = partialDecodeErr(err, len(soundData))
+			if decodeErr == nil {
+				return err
+			}
 		}
 
-		sb := &SoundBuffer{Data: F32ToUnsignedPCM16(soundData, nil)}
+		sb := &SoundBuffer{Data: convertChannels(soundData, format.Channels, int(ChanCount))}
 		s.Data = sb
-		s.Player = Ctx.NewPlayer(sb)
+		s.Player = newPlayer(sb)
 		s.PlayerSeeker = s.Player.(io.Seeker)
-		s.Info.Size = int64(len(sb.Data))
+		s.Info.Size = sb.Len()
+		s.Info.NativeSampleRate = SampleRate(format.SampleRate)
 	}
 
 	if s.Data == nil {
 		panic("invalid sound type. This is probably a bug!")
 	}
 
+	registerMemSound(s)
+	return decodeErr
+}
+
+// partialDecodeErr returns the warning to return along with a sound whose decoding failed with err after decoding decodedLen bytes,
+// or nil if the failure should fail loading instead
+func partialDecodeErr(err error, decodedLen int) error {
+
+	if decodedLen == 0 {
+		return nil
+	}
+
+	// Already a warning, for example an unexpected EOF (see SetUnexpectedEOFAsPartial)
+	var partialErr *PartialDecodeError
+	if errors.As(err, &partialErr) {
+		return err
+	}
+
+	if allowPartialDecode {
+		return &PartialDecodeError{Err: err}
+	}
+
 	return nil
 }
 
+// supportedSoundTypes are the types that can be decoded and played
+var supportedSoundTypes = []SoundType{
+	SoundType_MP3,
+	SoundType_WAV,
+	SoundType_OGG,
+}
+
+// SupportedSoundTypes returns the sound types that can be loaded and played.
+// Types not in this list might still be recognized by GetSoundFileType, but loading them returns ErrUnsupportedSoundType
+func SupportedSoundTypes() []SoundType {
+
+	types := make([]SoundType, len(supportedSoundTypes))
+	copy(types, supportedSoundTypes)
+	return types
+}
+
+// IsSoundTypeSupported returns true if sounds of type t can be loaded and played
+func IsSoundTypeSupported(t SoundType) bool {
+
+	for _, supported := range supportedSoundTypes {
+		if t == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// soundFileExtensions maps file extensions to the sound type they are recognized as, and is used
+// by both GetSoundFileType and SupportedExtensions so they can't get out of sync
+var soundFileExtensions = []struct {
+	Ext  string
+	Type SoundType
+}{
+	{".mp3", SoundType_MP3},
+	{".wav", SoundType_WAV},
+	{".wave", SoundType_WAV},
+	{".ogg", SoundType_OGG},
+	{".opus", soundType_OPUS},
+	{".m4a", soundType_AAC},
+	{".aac", soundType_AAC},
+}
+
+// SupportedExtensions returns the file extensions (e.g. ".mp3") of the sound types that can be loaded and played,
+// which is useful for things like the filter of an open file dialog. Extensions that are recognized but not supported aren't included
+func SupportedExtensions() []string {
+
+	exts := make([]string, 0, len(soundFileExtensions))
+	for _, se := range soundFileExtensions {
+		if IsSoundTypeSupported(se.Type) {
+			exts = append(exts, se.Ext)
+		}
+	}
+
+	return exts
+}
+
+// GetSoundFileType returns the type of the sound based on the file extension.
+// The returned type is only recognized, not necessarily supported, so use IsSoundTypeSupported to check that
 func GetSoundFileType(fpath string) SoundType {
 
 	ext := path.Ext(fpath)
-	switch ext {
-	case ".mp3":
-		return SoundType_MP3
-	case ".wav", ".wave":
-		return SoundType_WAV
-	case ".ogg":
-		return SoundType_OGG
-	default:
-		return SoundType_Unknown
+	for _, se := range soundFileExtensions {
+		if se.Ext == ext {
+			return se.Type
+		}
 	}
+
+	return SoundType_Unknown
 }
 
 // ReadAllFromReader takes an io.Reader and reads until error or io.EOF.
 //
 // If io.EOF is reached then read bytes are returned with a nil error.
-// If the reader returns an error that's not io.EOF then everything read till that point is returned along with the error
+// If the reader returns an error that's not io.EOF then everything read till that point is returned along with the error.
+// With SetUnexpectedEOFAsPartial(true), an io.ErrUnexpectedEOF after some data is returned as a *PartialDecodeError, which should be treated as a warning
 //
 // readingBufSize is the buffer used to read from reader.Read(). Bigger values might read more efficiently.
-// If readingBufSize<4096 then readingBufSize is set to 4096
+// If readingBufSize<4096 then readingBufSize is set to 4096. The reading buffer can be reused across calls with SetReadBufferPooling
 //
 // ouputBufSize is used to set the capacity of the final buffer to be returned. This can greatly improve performance
 // if you know the size of the output. It is allowed to have an outputBufSize that's smaller or larger than what the reader
@@ -554,7 +1861,9 @@ func ReadAllFromReader(reader io.Reader, readingBufSize, ouputBufSize uint64) ([
 		readingBufSize = 4096
 	}
 
-	tempBuf := make([]byte, readingBufSize)
+	tempBuf := getReadBuf(int(readingBufSize))
+	defer putReadBuf(tempBuf)
+
 	finalBuf := make([]byte, 0, ouputBufSize)
 	for {
 
@@ -565,23 +1874,153 @@ func ReadAllFromReader(reader io.Reader, readingBufSize, ouputBufSize uint64) ([
 			if err == io.EOF {
 				return finalBuf, nil
 			}
-			return finalBuf, err
+			return finalBuf, unexpectedEOFAsPartial(err, len(finalBuf))
+		}
+	}
+}
+
+// readAllOggPCM16 decodes all of r into PCM16. Unlike oggvorbis.ReadAll, the output buffer is allocated once using the stream length
+// instead of growing while decoding, and the float samples are converted in chunks rather than all at once.
+//
+// On errors the data decoded so far is returned along with the error. The format is nil only if the stream couldn't be opened
+func readAllOggPCM16(r io.Reader) ([]byte, *oggvorbis.Format, error) {
+
+	dec, err := oggvorbis.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format := &oggvorbis.Format{
+		SampleRate: dec.SampleRate(),
+		Channels:   dec.Channels(),
+		Bitrate:    dec.Bitrate(),
+	}
+
+	// Length is in samples per channel, and is zero if it can't be known (e.g. r isn't seekable).
+	// Each float becomes one 2-byte PCM16 value
+	outputSize := dec.Length() * int64(format.Channels) * 2
+	if err := checkInMemorySize(outputSize); err != nil {
+		return nil, format, err
+	}
+
+	floatBuf := make([]float32, 4096*format.Channels)
+	finalBuf := make([]byte, 0, outputSize)
+	for {
+
+		readCount, err := dec.Read(floatBuf)
+		if readCount > 0 {
+
+			start := len(finalBuf)
+			end := start + readCount*2
+			if end > cap(finalBuf) {
+				finalBuf = append(finalBuf, make([]byte, end-start)...)
+			}
+
+			finalBuf = finalBuf[:end]
+			f32ToPCM16(floatBuf[:readCount], finalBuf[start:end])
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				return finalBuf, format, nil
+			}
+			return finalBuf, format, unexpectedEOFAsPartial(err, len(finalBuf))
+		}
+	}
+}
+
+// unexpectedEOFAsPartial turns io.ErrUnexpectedEOF into a *PartialDecodeError if enabled and some data was read, and returns other errors as is
+func unexpectedEOFAsPartial(err error, readLen int) error {
+
+	if err == io.ErrUnexpectedEOF && unexpectedEOFIsPartial && readLen > 0 {
+		return &PartialDecodeError{Err: err}
+	}
+
+	return err
+}
+
+// getReadBuf returns a buffer of the given size, from the pool if pooling is enabled
+func getReadBuf(size int) []byte {
+
+	if readBufPooling {
+		if bufPtr, ok := readBufPool.Get().(*[]byte); ok && cap(*bufPtr) >= size {
+			return (*bufPtr)[:size]
 		}
 	}
+
+	return make([]byte, size)
+}
+
+// putReadBuf returns buf to the pool if pooling is enabled
+func putReadBuf(buf []byte) {
+
+	if readBufPooling {
+		readBufPool.Put(&buf)
+	}
 }
 
-// PlayTimeFromByteCount returns the time taken to play this many bytes
+// PlayTimeFromByteCount returns the time taken to play this many bytes.
+// Returns zero if called before Init
 func PlayTimeFromByteCount(byteCount int64) time.Duration {
+
+	if BytesPerSecond == 0 {
+		return 0
+	}
+
 	// timeToPlayInMs = timeToPlayInSec * 1000 = byteCount / bytesPerSecond * 1000
 	lenInMs := float64(byteCount) / float64(BytesPerSecond) * 1000
 	return time.Duration(lenInMs) * time.Millisecond
 }
 
-// PlayTimeFromByteCount returns how many bytes are needed to produce a sound that takes t time to play
+// ByteCountFromPlayTime returns how many bytes are needed to produce a sound that takes t time to play.
+// Returns zero if called before Init
 func ByteCountFromPlayTime(t time.Duration) int64 {
 	return t.Milliseconds() * BytesPerSecond / 1000
 }
 
+// PlayTimeFromByteCountFmt is like PlayTimeFromByteCount, but for PCM in the given format instead of the format wavy was initialized with.
+// This doesn't need Init. Returns zero if any of the format values is zero
+func PlayTimeFromByteCountFmt(byteCount int64, rate SampleRate, ch SoundChannelCount, depth SoundBitDepth) time.Duration {
+
+	bytesPerSecond := int64(rate) * int64(ch) * int64(depth)
+	if bytesPerSecond == 0 {
+		return 0
+	}
+
+	lenInMs := float64(byteCount) / float64(bytesPerSecond) * 1000
+	return time.Duration(lenInMs) * time.Millisecond
+}
+
+// ByteCountFromPlayTimeFmt is like ByteCountFromPlayTime, but for PCM in the given format instead of the format wavy was initialized with.
+// This doesn't need Init
+func ByteCountFromPlayTimeFmt(t time.Duration, rate SampleRate, ch SoundChannelCount, depth SoundBitDepth) int64 {
+	return t.Milliseconds() * int64(rate) * int64(ch) * int64(depth) / 1000
+}
+
+// alignToSample rounds byteCount down to a multiple of BytesPerSample, so that it points to the start of a sample
+func alignToSample(byteCount int64) int64 {
+
+	if BytesPerSample == 0 {
+		return byteCount
+	}
+
+	return byteCount - byteCount%BytesPerSample
+}
+
+// clampByteCount clamps byteCount to [0,max]
+func clampByteCount(byteCount, max int64) int64 {
+
+	if byteCount < 0 {
+		return 0
+	}
+
+	if byteCount > max {
+		return max
+	}
+
+	return byteCount
+}
+
 // clampF64 [min,max]
 func clamp01F64(x float64) float64 {
 
@@ -598,6 +2037,8 @@ func clamp01F64(x float64) float64 {
 
 // F32ToUnsignedPCM16 takes PCM data stored as float32 between [-1, 1]
 // and returns a byte array of uint16, where each two subsequent bytes represent one uint16.
+//
+// If dithering is enabled (see SetDithering) then values are dithered and rounded instead of truncated
 func F32ToUnsignedPCM16(fs []float32, outBuf []byte) []byte {
 
 	if outBuf == nil {
@@ -611,7 +2052,9 @@ func F32ToUnsignedPCM16(fs []float32, outBuf []byte) []byte {
 		// while positive values remain unchanged
 		x := fs[i]
 		var u16 uint16
-		if x < 0 {
+		if ditheringEnabled {
+			u16 = uint16(ditherF32ToI16(x))
+		} else if x < 0 {
 			u16 = uint16(x * -math.MinInt16)
 		} else {
 			u16 = uint16(x * math.MaxInt16)
@@ -624,3 +2067,57 @@ func F32ToUnsignedPCM16(fs []float32, outBuf []byte) []byte {
 
 	return outBuf
 }
+
+// F32ToPCM16HQ is like F32ToUnsignedPCM16 but rounds to the nearest value instead of truncating, using an int32 intermediate
+// that is saturated to the int16 range, so out of range input (e.g. 1.2) is clipped instead of wrapping around.
+//
+// Rounding halves the average quantization error compared to truncation, and avoids the small bias truncation adds towards zero.
+// The cost is a math.Round per sample, which makes it ~2x slower than F32ToUnsignedPCM16 (see the benchmarks), though both are
+// far cheaper than the decoding that produces the floats
+func F32ToPCM16HQ(fs []float32, outBuf []byte) []byte {
+
+	if outBuf == nil {
+		outBuf = make([]byte, len(fs)*2)
+	}
+
+	for i := 0; i < len(fs); i++ {
+
+		// Scaling by 32768 maps -1 exactly to MinInt16, and values that end up above MaxInt16 are saturated
+		v := int32(math.Round(float64(fs[i]) * -math.MinInt16))
+		if v > math.MaxInt16 {
+			v = math.MaxInt16
+		} else if v < math.MinInt16 {
+			v = math.MinInt16
+		}
+
+		u16 := uint16(int16(v))
+		baseIndex := i * 2
+		outBuf[baseIndex] = byte(u16 >> 0)
+		outBuf[baseIndex+1] = byte(u16 >> 8)
+	}
+
+	return outBuf
+}
+
+// f32ToPCM16 converts floats using the conversion selected by the package settings
+func f32ToPCM16(fs []float32, outBuf []byte) []byte {
+
+	if hqFloatConversion && !ditheringEnabled {
+		return F32ToPCM16HQ(fs, outBuf)
+	}
+
+	return F32ToUnsignedPCM16(fs, outBuf)
+}
+
+// ditherF32ToI16 scales x the same way F32ToUnsignedPCM16 does, then adds triangular (TPDF) noise of ±0.5 LSB and rounds.
+// This keeps the result within ±1 LSB of the exact scaled value
+func ditherF32ToI16(x float32) int16 {
+
+	scaled := float64(x) * math.MaxInt16
+	if x < 0 {
+		scaled = float64(x) * -math.MinInt16
+	}
+
+	noise := (rand.Float64() - rand.Float64()) * 0.5
+	return saturateToI16(scaled + noise)
+}
@@ -1,17 +1,81 @@
 package wavy_test
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/bloeys/wavy"
 )
 
+// TestBeforeInit must come before TestWavy, because tests run in source order and TestWavy inits wavy
+func TestBeforeInit(t *testing.T) {
+
+	if wavy.Ctx != nil {
+		t.Skip("wavy is already initialized")
+	}
+
+	if got := wavy.PlayTimeFromByteCount(70560); got != 0 {
+		t.Errorf("Expected '0' before init but got '%d'\n", got)
+		return
+	}
+
+	if got := wavy.ByteCountFromPlayTime(400 * time.Millisecond); got != 0 {
+		t.Errorf("Expected '0' before init but got '%d'\n", got)
+		return
+	}
+
+	s := &wavy.Sound{Info: wavy.SoundInfo{Size: 70560}}
+	if got := s.TotalTime(); got != 0 {
+		t.Errorf("Expected total time of '0' before init but got '%d'\n", got)
+		return
+	}
+}
+
 func TestWavy(t *testing.T) {
 	t.Run("Init", InitSubtest)
 	t.Run("MP3", MP3Subtest)
 	t.Run("Wav", WavSubtest)
 	t.Run("Ogg", OggSubtest)
+	t.Run("LoadDir", LoadDirSubtest)
+	t.Run("MP3BigID3", MP3BigID3Subtest)
+	t.Run("PartialDecode", PartialDecodeSubtest)
+	t.Run("ConcurrentLoad", ConcurrentLoadSubtest)
+	t.Run("RateMismatch", RateMismatchSubtest)
+	t.Run("MonoWav", MonoWavSubtest)
+	t.Run("Mmap", MmapSubtest)
+	t.Run("PlayAndClose", PlayAndCloseSubtest)
+	t.Run("Raw", RawSubtest)
+	t.Run("PlayIfNotPlaying", PlayIfNotPlayingSubtest)
+	t.Run("Source", SourceSubtest)
+	t.Run("TotalInMemoryBytes", TotalInMemoryBytesSubtest)
+	t.Run("BackwardSeekPolicy", BackwardSeekPolicySubtest)
+	t.Run("WriteTo", WriteToSubtest)
+	t.Run("Reset", ResetSubtest)
+	t.Run("MemCompressed", MemCompressedSubtest)
+	t.Run("ForkAtCurrent", ForkAtCurrentSubtest)
+	t.Run("Manifest", ManifestSubtest)
+	t.Run("Probe", ProbeSubtest)
+	t.Run("Tee", TeeSubtest)
+	t.Run("PercentTime", PercentTimeSubtest)
+	t.Run("CloseWhilePlaying", CloseWhilePlayingSubtest)
+	t.Run("GroupEffect", GroupEffectSubtest)
+	t.Run("ApplyEffects", ApplyEffectsSubtest)
+	t.Run("PlayTogether", PlayTogetherSubtest)
+	t.Run("AtEnd", AtEndSubtest)
+	t.Run("SeekOvershoot", SeekOvershootSubtest)
+	t.Run("Playlist", PlaylistSubtest)
+	t.Run("PrebufferRange", PrebufferRangeSubtest)
 }
 
 func InitSubtest(t *testing.T) {
@@ -36,6 +100,16 @@ func MP3Subtest(t *testing.T) {
 		return
 	}
 
+	if s.SourcePath() != fatihaFilepath {
+		t.Errorf("Expected source path to be '%s' but got '%s'\n", fatihaFilepath, s.SourcePath())
+		return
+	}
+
+	if _, ok := s.Data.(*wavy.Mp3Streamer); !ok {
+		t.Errorf("Expected streaming mp3 data to be an Mp3Streamer but got %T\n", s.Data)
+		return
+	}
+
 	s.PlayAsync()
 	time.Sleep(1 * time.Second)
 	s.Player.Pause()
@@ -46,6 +120,44 @@ func MP3Subtest(t *testing.T) {
 		return
 	}
 
+	// Only play 200ms from the middle of the sound
+	s.SetPlaybackRange(20*time.Second, 20*time.Second+200*time.Millisecond)
+	remTime = s.RemainingTime()
+	if remTime != 200*time.Millisecond {
+		t.Errorf("Expected remaining time to be 200ms after setting the playback range but got %dms\n", remTime.Milliseconds())
+		return
+	}
+
+	// Discard events from earlier plays so we only see the loop's events
+	for len(wavy.SoundEvents()) > 0 {
+		<-wavy.SoundEvents()
+	}
+
+	s.LoopAsync(2)
+	s.WaitLoop()
+
+	expectedEvents := []wavy.SoundEventType{wavy.SoundEventType_Started, wavy.SoundEventType_Looped, wavy.SoundEventType_Finished}
+	for _, expected := range expectedEvents {
+
+		select {
+		case e := <-wavy.SoundEvents():
+			if e.Type != expected || e.SoundID != s.ID() {
+				t.Errorf("Expected event '%s' for sound %d but got '%s' for sound %d\n", expected, s.ID(), e.Type, e.SoundID)
+				return
+			}
+		default:
+			t.Errorf("Expected event '%s' but no events were published\n", expected)
+			return
+		}
+	}
+
+	s.SetPlaybackRange(0, 0)
+	s.SeekToPercent(0)
+	if s.RemainingTime().Milliseconds() != fatihaLenMS {
+		t.Errorf("Expected remaining time to be %dms after clearing the playback range but got %dms\n", fatihaLenMS, s.RemainingTime().Milliseconds())
+		return
+	}
+
 	if err := s.Close(); err != nil {
 		t.Errorf("Closing streaming sound failed. Err: %s\n", err)
 		return
@@ -85,6 +197,11 @@ func MP3Subtest(t *testing.T) {
 		return
 	}
 
+	if s.Info.NativeSampleRate != wavy.SampleRate_44100 {
+		t.Errorf("Expected native sample rate to be %d but got %d\n", wavy.SampleRate_44100, s.Info.NativeSampleRate)
+		return
+	}
+
 	// 'tada.mp3' memory
 	s, err = wavy.NewSoundMem(tadaFilepath)
 	if err != nil {
@@ -107,12 +224,119 @@ func MP3Subtest(t *testing.T) {
 	s2.SeekToPercent(0.2)
 	s2.PlaySync()
 
+	s2.Rewind()
+	if s2.RemainingTime() != s2.TotalTime() {
+		t.Errorf("Expected remaining time to be '%s' after rewind but got '%s'\n", s2.TotalTime(), s2.RemainingTime())
+		return
+	}
+
 	s2.SeekToTime(400 * time.Millisecond)
 	s2.PlaySync()
 
+	s2.SeekToTime(400 * time.Millisecond)
+	s2.SeekBy(-100 * time.Millisecond)
+	if s2.Position() != 300*time.Millisecond {
+		t.Errorf("Expected position to be 300ms after seeking back by 100ms but got %dms\n", s2.Position().Milliseconds())
+		return
+	}
+
 	s3 := wavy.ClipInMemSoundPercent(s2, 0, 0.25)
+	if s3.Volume() != s2.Volume() {
+		t.Errorf("Expected clipped sound to have the volume of its source (%f) but got %f\n", s2.Volume(), s3.Volume())
+		return
+	}
+
 	s3.LoopAsync(3)
 	s3.WaitLoop()
+
+	parts := wavy.Split(s2, 3)
+	if len(parts) != 3 {
+		t.Errorf("Expected Split to return 3 parts but got %d\n", len(parts))
+		return
+	}
+
+	var joined []byte
+	for _, part := range parts {
+
+		if part.Info.Size%wavy.BytesPerSample != 0 || part.Info.Size != part.Data.(*wavy.SoundBuffer).Len() {
+			t.Errorf("Expected split parts to be frame aligned and have a matching size, but got a size of %d\n", part.Info.Size)
+			return
+		}
+
+		joined = append(joined, part.Data.(*wavy.SoundBuffer).Data...)
+	}
+
+	s2Data := s2.Data.(*wavy.SoundBuffer).Data
+	if !bytes.Equal(joined, s2Data[:int64(len(s2Data))-int64(len(s2Data))%wavy.BytesPerSample]) {
+		t.Errorf("Expected split parts to cover the whole sound in order\n")
+		return
+	}
+
+	if diff := parts[0].Info.Size - parts[2].Info.Size; diff < -wavy.BytesPerSample || diff > wavy.BytesPerSample {
+		t.Errorf("Expected split parts to be of equal length, but got %d and %d bytes\n", parts[0].Info.Size, parts[2].Info.Size)
+		return
+	}
+
+	parts[1].PlaySync()
+	for _, part := range parts {
+		part.Close()
+	}
+
+	s2.SeekToPercent(0.1)
+	left, right := s2.CurrentChannelRMS()
+	if left <= 0 || left > 1 || right <= 0 || right > 1 {
+		t.Errorf("Expected channel RMS values to be within (0,1] but got left=%f and right=%f\n", left, right)
+		return
+	}
+
+	// Stereo width of zero should produce identical channels
+	s4 := wavy.CopyInMemSound(s)
+	if s4.SourcePath() != s.SourcePath() {
+		t.Errorf("Expected copied sound to have the source path '%s' but got '%s'\n", s.SourcePath(), s4.SourcePath())
+		return
+	}
+
+	s4.SetStereoWidth(0)
+
+	monoData := s4.Data.(*wavy.SoundBuffer).Data
+	for i := 0; i+3 < len(monoData); i += 4 {
+		if monoData[i] != monoData[i+2] || monoData[i+1] != monoData[i+3] {
+			t.Errorf("Expected left and right channels to be equal at byte %d after setting stereo width to zero\n", i)
+			return
+		}
+	}
+
+	monoSound := wavy.ToMono(s)
+	monoSoundData := monoSound.Data.(*wavy.SoundBuffer).Data
+	if monoSound.Info.Size != s.Info.Size {
+		t.Errorf("Expected mono sound to have the same size as the original\n")
+		return
+	}
+
+	for i := 0; i+3 < len(monoSoundData); i += 4 {
+		if monoSoundData[i] != monoSoundData[i+2] || monoSoundData[i+1] != monoSoundData[i+3] {
+			t.Errorf("Expected left and right channels of ToMono output to be equal at byte %d\n", i)
+			return
+		}
+	}
+
+	stereoSound := wavy.ToStereo(monoSound)
+	if !bytes.Equal(stereoSound.Data.(*wavy.SoundBuffer).Data, monoSoundData) {
+		t.Errorf("Expected ToStereo in a stereo context to keep the data the same\n")
+		return
+	}
+
+	// Replacing the data with its first half should halve the length
+	halfData := monoData[:len(monoData)/8*4]
+	if err := s4.SetData(&wavy.SoundBuffer{Data: halfData}); err != nil {
+		t.Errorf("Failed to set sound data. Err: %s\n", err)
+		return
+	}
+
+	if s4.Info.Size != int64(len(halfData)) || s4.RemainingTime() != wavy.PlayTimeFromByteCount(int64(len(halfData))) {
+		t.Errorf("Expected size to be %d bytes after setting data but got %d\n", len(halfData), s4.Info.Size)
+		return
+	}
 }
 
 func WavSubtest(t *testing.T) {
@@ -126,15 +350,81 @@ func WavSubtest(t *testing.T) {
 	}
 	s.PlaySync()
 
+	wavReader, err := s.WavReader()
+	if err != nil {
+		t.Errorf("Failed to get WAV reader of memory sound. Err: %s\n", err)
+		return
+	}
+
+	wavBytes, err := io.ReadAll(wavReader)
+	if err != nil {
+		t.Errorf("Failed to read WAV reader. Err: %s\n", err)
+		return
+	}
+
+	if int64(len(wavBytes)) != 44+s.Info.Size || string(wavBytes[:4]) != "RIFF" || string(wavBytes[36:40]) != "data" {
+		t.Errorf("Expected a WAV of %d bytes with a valid header but got %d bytes\n", 44+s.Info.Size, len(wavBytes))
+		return
+	}
+
+	// Writing the buffer should give the same bytes as the WAV reader
+	writtenWav := &bytes.Buffer{}
+	if err := s.Data.(*wavy.SoundBuffer).WriteWav(writtenWav, wavy.SamplingRate, wavy.ChanCount, wavy.BitDepth); err != nil {
+		t.Errorf("Failed to write WAV. Err: %s\n", err)
+		return
+	}
+
+	if !bytes.Equal(writtenWav.Bytes(), wavBytes) {
+		t.Errorf("Expected written WAV to be the same as the WAV reader output\n")
+		return
+	}
+
 	// Wav streaming
 	s, err = wavy.NewSoundStreaming(wavFPath)
 	if err != nil {
 		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFPath, err)
 		return
 	}
+
+	if _, err := s.WavReader(); err != wavy.ErrNotInMemory {
+		t.Errorf("Expected ErrNotInMemory when getting the WAV reader of a streaming sound but got '%v'\n", err)
+		return
+	}
+
+	if _, err := wavy.CopyInMemSoundErr(s); err != wavy.ErrNotInMemory {
+		t.Errorf("Expected ErrNotInMemory when copying a streaming sound but got '%v'\n", err)
+		return
+	}
+
+	if _, err := wavy.ClipInMemSoundPercentErr(s, 0, 0.5); err != wavy.ErrNotInMemory {
+		t.Errorf("Expected ErrNotInMemory when clipping a streaming sound but got '%v'\n", err)
+		return
+	}
+
+	if err := s.Prebuffer(250 * time.Millisecond); err != nil {
+		t.Errorf("Failed to prebuffer streaming sound with path '%s'. Err: %s\n", wavFPath, err)
+		return
+	}
 	s.PlaySync()
 	s.SeekToPercent(0.5)
 	s.PlaySync()
+
+	// Seeking should be relative to the start of the sound data, not the start of the file
+	seekTime := s.TotalTime() / 2
+	s.SeekToTime(seekTime)
+
+	expectedRemTime := s.TotalTime() - seekTime
+	remTime := s.RemainingTime()
+	if remTime < expectedRemTime-2*time.Millisecond || remTime > expectedRemTime+2*time.Millisecond {
+		t.Errorf("Expected remaining time of streaming wav to be ~%s after seeking but got %s\n", expectedRemTime, remTime)
+		return
+	}
+
+	pos := s.Position()
+	if pos < seekTime-2*time.Millisecond || pos > seekTime+2*time.Millisecond {
+		t.Errorf("Expected position of streaming wav to be ~%s after seeking but got %s\n", seekTime, pos)
+		return
+	}
 }
 
 func OggSubtest(t *testing.T) {
@@ -145,6 +435,24 @@ func OggSubtest(t *testing.T) {
 		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", oggFPath, err)
 		return
 	}
+
+	// Seeking before the first play should make the play start from the seeked point
+	s.SeekToPercent(0.5)
+	halfTime := s.TotalTime() / 2
+	if diff := s.RemainingTime() - halfTime; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Expected remaining time to be about '%s' after seeking to 50%% before playing but got '%s'\n", halfTime, s.RemainingTime())
+		return
+	}
+
+	s.PlayAsync()
+	time.Sleep(50 * time.Millisecond)
+	if s.RemainingTime() >= halfTime {
+		t.Errorf("Expected play after a pre-play seek to continue from the seeked point, but remaining time is '%s'\n", s.RemainingTime())
+		return
+	}
+	s.Wait()
+
+	s.SeekToPercent(0)
 	s.PlaySync()
 
 	// Ogg streaming
@@ -156,24 +464,1978 @@ func OggSubtest(t *testing.T) {
 	s.PlaySync()
 	s.SeekToPercent(.5)
 	s.PlaySync()
+
+	// A 100ms loop range should still be playing long after 100ms
+	s.SetLoopRange(100*time.Millisecond, 200*time.Millisecond)
+	s.PlayAsync()
+	time.Sleep(350 * time.Millisecond)
+	if !s.IsPlaying() {
+		t.Errorf("Expected sound with a loop range to keep playing\n")
+		return
+	}
+
+	pos := s.Position()
+	s.Pause()
+	if pos < 100*time.Millisecond || pos > 200*time.Millisecond {
+		t.Errorf("Expected position to be within the loop range but got %dms\n", pos.Milliseconds())
+		return
+	}
+
+	s.SetLoopRange(0, 0)
+	s.SeekToPercent(0)
+	if s.RemainingTime() != s.TotalTime() {
+		t.Errorf("Expected remaining time to be '%s' after clearing the loop range but got '%s'\n", s.TotalTime(), s.RemainingTime())
+		return
+	}
 }
 
-func TestByteCountFromPlayTime(t *testing.T) {
+func LoadDirSubtest(t *testing.T) {
 
-	got := wavy.ByteCountFromPlayTime(400 * time.Millisecond)
-	expected := int64(70560)
-	if got != expected {
-		t.Errorf("Expected '%d' but got '%d'\n", expected, got)
+	const dirPath = "./test_audio_files"
+
+	sounds, err := wavy.LoadDir(dirPath, wavy.SoundMode_Streaming)
+	if err == nil {
+		t.Errorf("Expected an error because '%s' has sounds with the same name but different extensions\n", dirPath)
+		return
+	}
+
+	for _, name := range []string{"Fatiha", "tada", "camera"} {
+
+		s, ok := sounds[name]
+		if !ok {
+			t.Errorf("Expected sound '%s' to be loaded from '%s'\n", name, dirPath)
+			return
+		}
+		s.Close()
+	}
+
+	if len(sounds) != 3 {
+		t.Errorf("Expected 3 sounds to be loaded from '%s' but got %d\n", dirPath, len(sounds))
 		return
 	}
 }
 
-func TestPlayTimeFromByteCount(t *testing.T) {
+func MP3BigID3Subtest(t *testing.T) {
 
-	got := wavy.PlayTimeFromByteCount(70560)
-	expected := 400 * time.Millisecond
-	if got != expected {
-		t.Errorf("Expected '%d' but got '%d'\n", expected, got)
+	const tadaFilepath = "./test_audio_files/tada.mp3"
+
+	original, err := wavy.NewSoundMem(tadaFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", tadaFilepath, err)
+		return
+	}
+	defer original.Close()
+
+	tadaBytes, err := os.ReadFile(tadaFilepath)
+	if err != nil {
+		t.Errorf("Failed to read '%s'. Err: %s\n", tadaFilepath, err)
+		return
+	}
+
+	// 1MB of fake album art in front of the audio
+	apicData := append([]byte("\x00image/jpeg\x00\x03\x00"), make([]byte, 1024*1024)...)
+	taggedBytes := append(makeID3v2Tag(makeID3v2Frame("APIC", apicData)), tadaBytes...)
+
+	taggedFilepath := filepath.Join(t.TempDir(), "tagged.mp3")
+	if err := os.WriteFile(taggedFilepath, taggedBytes, 0644); err != nil {
+		t.Errorf("Failed to write '%s'. Err: %s\n", taggedFilepath, err)
+		return
+	}
+
+	for _, mode := range []wavy.SoundMode{wavy.SoundMode_Memory, wavy.SoundMode_Streaming} {
+
+		var s *wavy.Sound
+		if mode == wavy.SoundMode_Memory {
+			s, err = wavy.NewSoundMem(taggedFilepath)
+		} else {
+			s, err = wavy.NewSoundStreaming(taggedFilepath)
+		}
+
+		if err != nil {
+			t.Errorf("Failed to load %s sound with path '%s'. Err: %s\n", mode, taggedFilepath, err)
+			return
+		}
+
+		if s.TotalTime() != original.TotalTime() {
+			t.Errorf("Expected %s sound with big ID3 tag to have time '%s' but got '%s'\n", mode, original.TotalTime(), s.TotalTime())
+			return
+		}
+		s.Close()
+	}
+}
+
+func PartialDecodeSubtest(t *testing.T) {
+
+	const fatihaFilepath = "./test_audio_files/Fatiha.mp3"
+	const fatihaLenMS = 55484
+
+	fatihaBytes, err := os.ReadFile(fatihaFilepath)
+	if err != nil {
+		t.Errorf("Failed to read '%s'. Err: %s\n", fatihaFilepath, err)
+		return
+	}
+
+	// Cut the file in the middle of a frame
+	truncatedFilepath := filepath.Join(t.TempDir(), "truncated.mp3")
+	if err := os.WriteFile(truncatedFilepath, fatihaBytes[:len(fatihaBytes)/2+7], 0644); err != nil {
+		t.Errorf("Failed to write '%s'. Err: %s\n", truncatedFilepath, err)
+		return
+	}
+
+	full, err := wavy.NewSoundMem(fatihaFilepath)
+	if err != nil {
+		t.Errorf("Failed to load '%s'. Err: %s\n", fatihaFilepath, err)
+		return
+	}
+	fullSize := full.Info.Size
+	full.Close()
+
+	// A frame cut in the middle is an unexpected EOF, which we want reported as a partial decode too
+	wavy.SetAllowPartialDecode(true)
+	wavy.SetUnexpectedEOFAsPartial(true)
+	defer wavy.SetAllowPartialDecode(false)
+	defer wavy.SetUnexpectedEOFAsPartial(false)
+
+	s, err := wavy.NewSoundMem(truncatedFilepath)
+	if s == nil {
+		t.Errorf("Expected a sound from the partially decoded file '%s'. Err: %s\n", truncatedFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	var partialErr *wavy.PartialDecodeError
+	if !errors.As(err, &partialErr) {
+		t.Errorf("Expected a PartialDecodeError for the truncated file but got '%v'\n", err)
+		return
+	}
+
+	if s.Info.Size <= 0 || s.Info.Size >= fullSize {
+		t.Errorf("Expected truncated sound to have data shorter than the full decode of %d bytes but got %d bytes\n", fullSize, s.Info.Size)
+		return
+	}
+
+	if s.TotalTime().Milliseconds() >= fatihaLenMS {
+		t.Errorf("Expected truncated sound to be shorter than %dms but got %dms\n", fatihaLenMS, s.TotalTime().Milliseconds())
+		return
+	}
+
+	s.PlayAsync()
+	time.Sleep(100 * time.Millisecond)
+	s.Pause()
+}
+
+// ConcurrentLoadSubtest loads sounds from many goroutines at once, and is most useful when run with -race
+func ConcurrentLoadSubtest(t *testing.T) {
+
+	fpaths := []string{"./test_audio_files/tada.mp3", "./test_audio_files/camera.wav", "./test_audio_files/camera.ogg"}
+
+	const loadsPerFile = 8
+	errs := make(chan error, 2*loadsPerFile*len(fpaths))
+
+	wg := sync.WaitGroup{}
+	for _, fpath := range fpaths {
+		for i := 0; i < loadsPerFile; i++ {
+
+			wg.Add(2)
+			go func(fpath string) {
+				defer wg.Done()
+
+				s, err := wavy.NewSoundMem(fpath)
+				if err != nil {
+					errs <- err
+					return
+				}
+				s.Close()
+			}(fpath)
+
+			go func(fpath string) {
+				defer wg.Done()
+
+				s, err := wavy.NewSoundStreaming(fpath)
+				if err != nil {
+					errs <- err
+					return
+				}
+				s.Close()
+			}(fpath)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Failed to load sound concurrently. Err: %s\n", err)
+	}
+}
+
+func RateMismatchSubtest(t *testing.T) {
+
+	fpath := filepath.Join(t.TempDir(), "sine48k.wav")
+	if err := os.WriteFile(fpath, makeTestWav(48000, 2, 4800), 0644); err != nil {
+		t.Errorf("Failed to write test wav. Err: %s\n", err)
+		return
+	}
+
+	_, err := wavy.NewSoundMem(fpath)
+	if !errors.Is(err, wavy.ErrSampleRateMismatch) {
+		t.Errorf("Expected loading a 48000 Hz file in a 44100 Hz context to fail with ErrSampleRateMismatch but got '%v'\n", err)
+		return
+	}
+
+	_, err = wavy.NewSoundStreaming(fpath)
+	if !errors.Is(err, wavy.ErrSampleRateMismatch) {
+		t.Errorf("Expected streaming a 48000 Hz file in a 44100 Hz context to fail with ErrSampleRateMismatch but got '%v'\n", err)
+		return
+	}
+
+	wavy.SetRateMismatchPolicy(wavy.RateMismatchPolicy_PlayAnyway)
+	defer wavy.SetRateMismatchPolicy(wavy.RateMismatchPolicy_Error)
+
+	s, err := wavy.NewSoundMem(fpath)
+	if err != nil {
+		t.Errorf("Expected loading a 48000 Hz file to succeed with RateMismatchPolicy_PlayAnyway but got '%s'\n", err)
+		return
+	}
+
+	if s.Info.NativeSampleRate != 48000 {
+		t.Errorf("Expected native sample rate to be 48000 but got %d\n", s.Info.NativeSampleRate)
+		return
+	}
+	s.Close()
+}
+
+func MonoWavSubtest(t *testing.T) {
+
+	fpath := filepath.Join(t.TempDir(), "sine_mono.wav")
+	if err := os.WriteFile(fpath, makeTestWav(44100, 1, 44100), 0644); err != nil {
+		t.Errorf("Failed to write test wav. Err: %s\n", err)
+		return
+	}
+
+	s, err := wavy.NewSoundMem(fpath)
+	if err != nil {
+		t.Errorf("Failed to load mono wav. Err: %s\n", err)
+		return
+	}
+	defer s.Close()
+
+	// One second of mono should be one second in a stereo context, with the size of the converted buffer
+	if s.TotalTime() != time.Second || s.Info.Size != wavy.BytesPerSecond {
+		t.Errorf("Expected mono wav to be 1s and %d bytes but got %s and %d bytes\n", wavy.BytesPerSecond, s.TotalTime(), s.Info.Size)
 		return
 	}
 }
+
+func MmapSubtest(t *testing.T) {
+
+	for _, fpath := range []string{"./test_audio_files/tada.mp3", "./test_audio_files/camera.wav", "./test_audio_files/camera.ogg"} {
+
+		s, err := wavy.NewSoundMmap(fpath)
+		if err != nil {
+			t.Errorf("Failed to load memory mapped sound with path '%s'. Err: %s\n", fpath, err)
+			return
+		}
+
+		s.SeekToPercent(0.5)
+		s.PlaySync()
+
+		if err := s.Close(); err != nil {
+			t.Errorf("Failed to close memory mapped sound with path '%s'. Err: %s\n", fpath, err)
+			return
+		}
+	}
+}
+
+func PlayAndCloseSubtest(t *testing.T) {
+
+	const tadaFilepath = "./test_audio_files/tada.mp3"
+	s, err := wavy.NewSoundMem(tadaFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", tadaFilepath, err)
+		return
+	}
+
+	s.PlayAndClose()
+	deadline := time.Now().Add(s.TotalTime() + time.Second)
+	for !s.IsClosed() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !s.IsClosed() {
+		t.Errorf("Expected sound to be closed after it finished playing\n")
+		return
+	}
+}
+
+func RawSubtest(t *testing.T) {
+
+	// One second of mono 16-bit PCM, which is the wav data without the 44 byte header
+	fpath := filepath.Join(t.TempDir(), "sine.raw")
+	if err := os.WriteFile(fpath, makeTestWav(44100, 1, 44100)[44:], 0644); err != nil {
+		t.Errorf("Failed to write test raw file. Err: %s\n", err)
+		return
+	}
+
+	s, err := wavy.NewSoundMemRaw(fpath, wavy.SampleRate_44100, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2)
+	if err != nil {
+		t.Errorf("Failed to load raw memory sound. Err: %s\n", err)
+		return
+	}
+
+	if s.TotalTime() != time.Second {
+		t.Errorf("Expected raw memory sound to be 1s but got %s\n", s.TotalTime())
+		return
+	}
+	s.Close()
+
+	s, err = wavy.NewSoundStreamingRaw(fpath, wavy.SampleRate_44100, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2)
+	if err != nil {
+		t.Errorf("Failed to load raw streaming sound. Err: %s\n", err)
+		return
+	}
+	defer s.Close()
+
+	if s.TotalTime() != time.Second {
+		t.Errorf("Expected raw streaming sound to be 1s but got %s\n", s.TotalTime())
+		return
+	}
+
+	s.SeekToPercent(0.75)
+	s.PlaySync()
+}
+
+func PlayIfNotPlayingSubtest(t *testing.T) {
+
+	const tadaFilepath = "./test_audio_files/tada.mp3"
+	s, err := wavy.NewSoundMem(tadaFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", tadaFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	if !s.PlayIfNotPlaying() {
+		t.Errorf("Expected PlayIfNotPlaying to play a sound that isn't playing\n")
+		return
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if s.PlayIfNotPlaying() {
+		t.Errorf("Expected PlayIfNotPlaying to do nothing while the sound is playing\n")
+		return
+	}
+
+	// Retriggering goes back to the start while still playing
+	s.Retrigger()
+	if !s.IsPlaying() || s.RemainingTime() < s.TotalTime()-50*time.Millisecond {
+		t.Errorf("Expected Retrigger to play from the start but remaining time is '%s' of '%s'\n", s.RemainingTime(), s.TotalTime())
+		return
+	}
+	s.Wait()
+}
+
+func SourceSubtest(t *testing.T) {
+
+	const oggFilepath = "./test_audio_files/camera.ogg"
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	tests := []struct {
+		Load          func(string) (*wavy.Sound, error)
+		Path          string
+		SeekBackCheap bool
+	}{
+		{Load: wavy.NewSoundMem, Path: oggFilepath, SeekBackCheap: true},
+		{Load: wavy.NewSoundStreaming, Path: oggFilepath, SeekBackCheap: false},
+		{Load: wavy.NewSoundStreaming, Path: wavFilepath, SeekBackCheap: true},
+	}
+
+	for _, tt := range tests {
+
+		s, err := tt.Load(tt.Path)
+		if err != nil {
+			t.Errorf("Failed to load sound with path '%s'. Err: %s\n", tt.Path, err)
+			return
+		}
+
+		if s.Data.Size() != s.Info.Size {
+			t.Errorf("Expected source size of '%s' (%s) to be %d but got %d\n", tt.Path, s.Info.Mode, s.Info.Size, s.Data.Size())
+		}
+
+		if s.Data.CanSeekBackCheaply() != tt.SeekBackCheap {
+			t.Errorf("Expected CanSeekBackCheaply of '%s' (%s) to be %v\n", tt.Path, s.Info.Mode, tt.SeekBackCheap)
+		}
+		s.Close()
+	}
+}
+
+func TotalInMemoryBytesSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	before := wavy.TotalInMemoryBytes()
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+
+	if wavy.TotalInMemoryBytes() != before+s.Info.Size {
+		t.Errorf("Expected total in-memory bytes to be %d after loading but got %d\n", before+s.Info.Size, wavy.TotalInMemoryBytes())
+	}
+
+	// Copies share data so they don't add to the total
+	sCopy := wavy.CopyInMemSound(s)
+	sClip := wavy.ClipInMemSoundPercent(s, 0.25, 0.5)
+	if wavy.TotalInMemoryBytes() != before+s.Info.Size {
+		t.Errorf("Expected copies to not change total in-memory bytes, but got %d instead of %d\n", wavy.TotalInMemoryBytes(), before+s.Info.Size)
+	}
+
+	s.Close()
+	if wavy.TotalInMemoryBytes() != before+s.Info.Size {
+		t.Errorf("Expected data still used by copies to be counted, but got %d instead of %d\n", wavy.TotalInMemoryBytes(), before+s.Info.Size)
+	}
+
+	sCopy.Close()
+	sClip.Close()
+	if wavy.TotalInMemoryBytes() != before {
+		t.Errorf("Expected total in-memory bytes to be %d after closing but got %d\n", before, wavy.TotalInMemoryBytes())
+	}
+}
+
+func BackwardSeekPolicySubtest(t *testing.T) {
+
+	const oggFilepath = "./test_audio_files/camera.ogg"
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	wavy.SetBackwardSeekPolicy(wavy.BackwardSeekPolicy_Error)
+	defer wavy.SetBackwardSeekPolicy(wavy.BackwardSeekPolicy_Allow)
+
+	s, err := wavy.NewSoundStreaming(oggFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", oggFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	if s.CanSeekBackward() {
+		t.Errorf("Expected streaming ogg to not seek backward cheaply\n")
+		return
+	}
+
+	if err := s.SeekToPercentErr(0.5); err != nil {
+		t.Errorf("Expected forward seek to work but got '%s'\n", err)
+		return
+	}
+
+	if err := s.SeekToPercentErr(0.25); err != wavy.ErrExpensiveBackwardSeek {
+		t.Errorf("Expected ErrExpensiveBackwardSeek on backward seek but got '%v'\n", err)
+		return
+	}
+
+	if s.Position() < s.TotalTime()/2-time.Millisecond {
+		t.Errorf("Expected refused seek to not move the sound, but position is '%s' of '%s'\n", s.Position(), s.TotalTime())
+		return
+	}
+
+	// WAV seeks cheaply so it isn't affected
+	s2, err := wavy.NewSoundStreaming(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s2.Close()
+
+	s2.SeekToPercent(0.5)
+	if err := s2.SeekToPercentErr(0.25); err != nil || !s2.CanSeekBackward() {
+		t.Errorf("Expected backward seek on streaming wav to work but got '%v'\n", err)
+		return
+	}
+}
+
+func SeekOvershootSubtest(t *testing.T) {
+
+	rawPath := filepath.Join(t.TempDir(), "sine.raw")
+	if err := os.WriteFile(rawPath, makeTestWav(44100, 1, 44100)[44:], 0644); err != nil {
+		t.Errorf("Failed to write test raw file. Err: %s\n", err)
+		return
+	}
+
+	loaders := map[string]func() (*wavy.Sound, error){
+		"MemWav":       func() (*wavy.Sound, error) { return wavy.NewSoundMem("./test_audio_files/camera.wav") },
+		"StreamingWav": func() (*wavy.Sound, error) { return wavy.NewSoundStreaming("./test_audio_files/camera.wav") },
+		"StreamingOgg": func() (*wavy.Sound, error) { return wavy.NewSoundStreaming("./test_audio_files/camera.ogg") },
+		"StreamingMp3": func() (*wavy.Sound, error) { return wavy.NewSoundStreaming("./test_audio_files/camera.mp3") },
+		"MemRaw": func() (*wavy.Sound, error) {
+			return wavy.NewSoundMemRaw(rawPath, wavy.SampleRate_44100, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2)
+		},
+		"StreamingRaw": func() (*wavy.Sound, error) {
+			return wavy.NewSoundStreamingRaw(rawPath, wavy.SampleRate_44100, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2)
+		},
+	}
+
+	defer wavy.SetSeekOvershootPolicy(wavy.SeekOvershootPolicy_Clamp)
+	for name, load := range loaders {
+
+		s, err := load()
+		if err != nil {
+			t.Errorf("%s: Failed to load sound. Err: %s\n", name, err)
+			return
+		}
+
+		// Times only have millisecond precision, so one millisecond is the smallest step past the end
+		end := s.TotalTime()
+		pastEnd := end + time.Millisecond
+
+		wavy.SetSeekOvershootPolicy(wavy.SeekOvershootPolicy_Error)
+		if err := s.SeekToTimeErr(end); err != nil {
+			s.Close()
+			t.Errorf("%s: Expected seeking exactly to the end to work but got '%s'\n", name, err)
+			return
+		}
+
+		s.SeekToTime(0)
+		if err := s.SeekToTimeErr(pastEnd); err != wavy.ErrSeekPastEnd {
+			s.Close()
+			t.Errorf("%s: Expected ErrSeekPastEnd when seeking past the end but got '%v'\n", name, err)
+			return
+		}
+
+		if s.Position() != 0 {
+			s.Close()
+			t.Errorf("%s: Expected refused seek to not move the sound, but position is '%s'\n", name, s.Position())
+			return
+		}
+
+		wavy.SetSeekOvershootPolicy(wavy.SeekOvershootPolicy_Clamp)
+		if err := s.SeekToTimeErr(pastEnd); err != nil || s.Position() != end {
+			s.Close()
+			t.Errorf("%s: Expected seek past the end to be clamped to '%s', but got position '%s' and err '%v'\n", name, end, s.Position(), err)
+			return
+		}
+
+		s.Close()
+	}
+}
+
+func PlaylistSubtest(t *testing.T) {
+
+	// The quiet track is the loud one at half amplitude, so matching both to the median loudness
+	// lowers the loud one to 0.75 and raises the quiet one to 1.5
+	loud := makeTestWav(44100, 2, 44100/5)
+	quiet := append([]byte{}, loud...)
+	for i := 44; i+1 < len(quiet); i += 2 {
+		binary.LittleEndian.PutUint16(quiet[i:], uint16(int16(binary.LittleEndian.Uint16(quiet[i:]))/2))
+	}
+
+	dir := t.TempDir()
+	loudPath := filepath.Join(dir, "loud.wav")
+	quietPath := filepath.Join(dir, "quiet.wav")
+	if err := os.WriteFile(loudPath, loud, 0644); err != nil {
+		t.Errorf("Failed to write test wav file. Err: %s\n", err)
+		return
+	}
+
+	if err := os.WriteFile(quietPath, quiet, 0644); err != nil {
+		t.Errorf("Failed to write test wav file. Err: %s\n", err)
+		return
+	}
+
+	p, err := wavy.NewPlaylist(loudPath, quietPath, "./test_audio_files/does_not_exist.wav")
+	if err == nil || p == nil {
+		t.Errorf("Expected playlist with the loaded tracks and an error for the missing file, but got playlist '%v' and err '%v'\n", p, err)
+		return
+	}
+	defer p.Close()
+
+	tracks := p.Tracks()
+	if len(tracks) != 2 {
+		t.Errorf("Expected 2 tracks but got %d\n", len(tracks))
+		return
+	}
+
+	if math.Abs(tracks[0].Gain-0.75) > 0.01 || math.Abs(tracks[1].Gain-1.5) > 0.01 {
+		t.Errorf("Expected gains of 0.75 and 1.5 but got %f and %f\n", tracks[0].Gain, tracks[1].Gain)
+		return
+	}
+
+	// Next and Prev wrap around
+	p.Prev()
+	if p.Current().Path != quietPath {
+		t.Errorf("Expected Prev on the first track to move to the last one, but current is '%s'\n", p.Current().Path)
+		return
+	}
+
+	p.Next()
+	if p.Current().Path != loudPath || p.CurrentPos() != 0 {
+		t.Errorf("Expected Next on the last track to move to the first one, but current is '%s'\n", p.Current().Path)
+		return
+	}
+
+	p.Shuffle()
+	if p.CurrentPos() != 0 || p.Current().Path != loudPath {
+		t.Errorf("Expected shuffle to keep the current track first\n")
+		return
+	}
+
+	// Both tracks autoplay then the playlist stops at its first track
+	first, second := p.Tracks()[0].Sound, p.Tracks()[1].Sound
+	p.Play()
+	for i := 0; i < 300 && p.IsPlaying(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if p.IsPlaying() || p.CurrentPos() != 0 {
+		t.Errorf("Expected playlist to stop at its first track after playing all tracks\n")
+		return
+	}
+
+	if first.Stats().PlayCount != 1 || second.Stats().PlayCount != 1 {
+		t.Errorf("Expected each track to play once, but play counts are %d and %d\n", first.Stats().PlayCount, second.Stats().PlayCount)
+		return
+	}
+
+	// Pausing stops autoplay
+	p.Play()
+	time.Sleep(50 * time.Millisecond)
+	p.Pause()
+	time.Sleep(300 * time.Millisecond)
+	if p.IsPlaying() || p.CurrentPos() != 0 || second.Stats().PlayCount != 1 {
+		t.Errorf("Expected paused playlist to not move to the next track\n")
+		return
+	}
+}
+
+func PrebufferRangeSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundStreaming(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	if err := s.SetPlaybackRange(100*time.Millisecond, 300*time.Millisecond); err != nil {
+		t.Errorf("Failed to set playback range. Err: %s\n", err)
+		return
+	}
+
+	// Prebuffering must keep the range in effect
+	if err := s.Prebuffer(100 * time.Millisecond); err != nil {
+		t.Errorf("Failed to prebuffer. Err: %s\n", err)
+		return
+	}
+
+	if s.RemainingTime() != 200*time.Millisecond {
+		t.Errorf("Expected remaining time to be 200ms after prebuffering a range but got '%s'\n", s.RemainingTime())
+		return
+	}
+
+	// A new range replaces the old one instead of being limited by it
+	if err := s.SetPlaybackRange(400*time.Millisecond, 450*time.Millisecond); err != nil {
+		t.Errorf("Failed to set playback range. Err: %s\n", err)
+		return
+	}
+
+	if s.RemainingTime() != 50*time.Millisecond {
+		t.Errorf("Expected remaining time to be 50ms after replacing the range but got '%s'\n", s.RemainingTime())
+		return
+	}
+
+	s.Close()
+	if err := s.SetPlaybackRange(0, 0); err != wavy.ErrSoundClosed {
+		t.Errorf("Expected ErrSoundClosed when setting the range of a closed sound but got '%v'\n", err)
+		return
+	}
+}
+
+func WriteToSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	memSound, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer memSound.Close()
+
+	streamSound, err := wavy.NewSoundStreaming(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer streamSound.Close()
+
+	memPCM := &bytes.Buffer{}
+	n, err := memSound.WriteTo(memPCM)
+	if err != nil || n != memSound.Info.Size {
+		t.Errorf("Expected WriteTo to write %d bytes of the memory sound but wrote %d. Err: %v\n", memSound.Info.Size, n, err)
+		return
+	}
+
+	streamPCM := &bytes.Buffer{}
+	if _, err := streamSound.WriteTo(streamPCM); err != nil {
+		t.Errorf("Failed to write streaming sound. Err: %s\n", err)
+		return
+	}
+
+	if !bytes.Equal(memPCM.Bytes(), streamPCM.Bytes()) {
+		t.Errorf("Expected memory and streaming sounds to write the same PCM\n")
+		return
+	}
+
+	if memSound.BytesRead() != n || streamSound.BytesRead() != n {
+		t.Errorf("Expected both sounds to have read %d bytes, but memory read %d and streaming read %d\n", n, memSound.BytesRead(), streamSound.BytesRead())
+		return
+	}
+
+	if memSound.RemainingTime() != 0 {
+		t.Errorf("Expected WriteTo to move the sound to its end but remaining time is '%s'\n", memSound.RemainingTime())
+		return
+	}
+
+	// Only the rest of the sound is written
+	memSound.SeekToPercent(0.5)
+	memPCM.Reset()
+	halfPos := memSound.Info.Size / 2
+	halfPos -= halfPos % wavy.BytesPerSample
+	if n, _ := memSound.WriteTo(memPCM); n != memSound.Info.Size-halfPos {
+		t.Errorf("Expected WriteTo to write the second half (%d bytes) but wrote %d\n", memSound.Info.Size-halfPos, n)
+		return
+	}
+}
+
+func TeeSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	captured := &bytes.Buffer{}
+	if err := s.Tee(captured); err != nil {
+		t.Errorf("Failed to tee sound. Err: %s\n", err)
+		return
+	}
+
+	if _, ok := s.Data.(*wavy.SoundBuffer); !ok {
+		t.Errorf("Expected tee to keep the data of a memory sound as a SoundBuffer but got %T\n", s.Data)
+		return
+	}
+
+	s.PlaySync()
+	if !bytes.Equal(captured.Bytes(), s.Data.(*wavy.SoundBuffer).Data) || s.TeeErr() != nil {
+		t.Errorf("Expected tee to capture all %d bytes that were played but captured %d. Err: %v\n", s.Info.Size, captured.Len(), s.TeeErr())
+		return
+	}
+
+	// Removing the tee stops capturing
+	s.Tee(nil)
+	captured.Reset()
+	s.SeekToPercent(0)
+	s.PlaySync()
+	if captured.Len() != 0 {
+		t.Errorf("Expected nothing to be captured after removing the tee but got %d bytes\n", captured.Len())
+		return
+	}
+}
+
+func PercentTimeSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	total := s.TotalTime()
+	if s.TimeAtPercent(0.5) != total/2 || s.TimeAtPercent(-1) != 0 || s.TimeAtPercent(2) != total {
+		t.Errorf("Expected TimeAtPercent to give '%s' at 0.5 and clamp to [0, %s] but got '%s', '%s' and '%s'\n",
+			total/2, total, s.TimeAtPercent(0.5), s.TimeAtPercent(-1), s.TimeAtPercent(2))
+		return
+	}
+
+	if math.Abs(s.PercentAtTime(total/2)-0.5) > 1e-6 || s.PercentAtTime(-time.Second) != 0 || s.PercentAtTime(total+time.Second) != 1 {
+		t.Errorf("Expected PercentAtTime to give 0.5 at '%s' and clamp to [0, 1] but got %f, %f and %f\n",
+			total/2, s.PercentAtTime(total/2), s.PercentAtTime(-time.Second), s.PercentAtTime(total+time.Second))
+		return
+	}
+
+	if (&wavy.Sound{}).PercentAtTime(time.Second) != 0 {
+		t.Errorf("Expected PercentAtTime of an empty sound to be zero\n")
+		return
+	}
+}
+
+func CloseWhilePlayingSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+
+	goroutinesBefore := runtime.NumGoroutine()
+	s.LoopAsync(-1)
+	time.Sleep(50 * time.Millisecond)
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected closing a looping sound to succeed but got '%s'\n", err)
+		return
+	}
+
+	if s.IsLooping {
+		t.Errorf("Expected closing to stop the loop\n")
+		return
+	}
+
+	// The loop and finish watcher goroutines exit on their next check
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutinesBefore && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if runtime.NumGoroutine() > goroutinesBefore {
+		t.Errorf("Expected goroutines to go back to %d after closing but there are %d\n", goroutinesBefore, runtime.NumGoroutine())
+		return
+	}
+}
+
+// halveEffect halves the amplitude of every sample
+type halveEffect struct{}
+
+func (halveEffect) Process(pcm []byte) []byte {
+
+	for i := 0; i+1 < len(pcm); i += 2 {
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(int16(binary.LittleEndian.Uint16(pcm[i:]))/2))
+	}
+
+	return pcm
+}
+
+func GroupEffectSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	streamSound, err := wavy.NewSoundStreaming(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer streamSound.Close()
+
+	dry := append([]byte(nil), s.Data.(*wavy.SoundBuffer).Data...)
+	wet := halveEffect{}.Process(append([]byte(nil), dry...))
+
+	s.SetGroup("underwater")
+	streamSound.SetGroup("underwater")
+	s.SeekToPercent(0.5)
+	pos := s.Position()
+	if err := wavy.SetGroupEffect("underwater", halveEffect{}); err != nil {
+		t.Errorf("Failed to set group effect. Err: %s\n", err)
+		return
+	}
+	defer wavy.SetGroupEffect("underwater", nil)
+
+	if !bytes.Equal(s.Data.(*wavy.SoundBuffer).Data, wet) {
+		t.Errorf("Expected group effect to be applied to the in-memory member\n")
+		return
+	}
+
+	if s.Position() != pos {
+		t.Errorf("Expected applying the group effect to keep the position at '%s' but it's '%s'\n", pos, s.Position())
+		return
+	}
+
+	if _, ok := streamSound.Data.(*wavy.WavStreamer); !ok {
+		t.Errorf("Expected streaming member to be unaffected by the group effect\n")
+		return
+	}
+
+	// Leaving the group restores the original data, and joining applies the effect again
+	s.SetGroup("")
+	if !bytes.Equal(s.Data.(*wavy.SoundBuffer).Data, dry) {
+		t.Errorf("Expected leaving the group to restore the original data\n")
+		return
+	}
+
+	s.SetGroup("underwater")
+	if !bytes.Equal(s.Data.(*wavy.SoundBuffer).Data, wet) {
+		t.Errorf("Expected joining the group to apply its effect\n")
+		return
+	}
+
+	s.PlaySync()
+
+	wavy.SetGroupEffect("underwater", nil)
+	if !bytes.Equal(s.Data.(*wavy.SoundBuffer).Data, dry) {
+		t.Errorf("Expected removing the group effect to restore the original data\n")
+		return
+	}
+}
+
+func ApplyEffectsSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	original := append([]byte(nil), s.Data.(*wavy.SoundBuffer).Data...)
+	effects := []wavy.Effect{wavy.LowPassEffect{CutoffHz: 1000, Q: 0.7071}, halveEffect{}, wavy.GainEffect{Gain: 1.5}}
+
+	expected := append([]byte(nil), original...)
+	for _, effect := range effects {
+		expected = effect.Process(expected)
+	}
+
+	processed := wavy.ApplyEffects(s, effects...)
+	defer processed.Close()
+
+	if !bytes.Equal(processed.Data.(*wavy.SoundBuffer).Data, expected) || processed.Info.Size != int64(len(expected)) {
+		t.Errorf("Expected ApplyEffects to run the effects in order\n")
+		return
+	}
+
+	if !bytes.Equal(s.Data.(*wavy.SoundBuffer).Data, original) {
+		t.Errorf("Expected ApplyEffects to not change the source sound\n")
+		return
+	}
+
+	processed.PlaySync()
+}
+
+func PlayTogetherSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	memSound, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer memSound.Close()
+
+	streamSound, err := wavy.NewSoundStreaming(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer streamSound.Close()
+
+	wavy.PlayTogether(memSound, streamSound)
+	if !memSound.IsPlaying() || !streamSound.IsPlaying() {
+		t.Errorf("Expected PlayTogether to play all the sounds\n")
+		return
+	}
+
+	// Starting together is best effort, so we only check that the sounds are close
+	time.Sleep(100 * time.Millisecond)
+	if diff := memSound.Position() - streamSound.Position(); diff < -20*time.Millisecond || diff > 20*time.Millisecond {
+		t.Errorf("Expected sounds started together to be close, but they are at '%s' and '%s'\n", memSound.Position(), streamSound.Position())
+		return
+	}
+
+	memSound.Wait()
+	streamSound.Wait()
+
+	// Sounds must not start while all sounds are paused
+	memSound.Rewind()
+	wavy.PauseAllSounds()
+	wavy.PlayTogether(memSound)
+	time.Sleep(50 * time.Millisecond)
+	if memSound.Position() > 0 {
+		t.Errorf("Expected PlayTogether to keep the context suspended after PauseAllSounds, but the sound is at '%s'\n", memSound.Position())
+	}
+
+	wavy.ResumeAllSounds()
+	memSound.Wait()
+}
+
+func AtEndSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	memSound, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer memSound.Close()
+
+	streamSound, err := wavy.NewSoundStreaming(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load streaming sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer streamSound.Close()
+
+	for _, s := range []*wavy.Sound{memSound, streamSound} {
+
+		if s.AtEnd() {
+			t.Errorf("Expected %s sound to not be at its end before playing\n", s.Info.Mode)
+			return
+		}
+
+		// Paused in the middle is not the end
+		s.PlayAsync()
+		time.Sleep(50 * time.Millisecond)
+		s.Pause()
+		if s.AtEnd() {
+			t.Errorf("Expected paused %s sound to not be at its end\n", s.Info.Mode)
+			return
+		}
+
+		s.PlaySync()
+		if !s.AtEnd() {
+			t.Errorf("Expected %s sound to be at its end after playing\n", s.Info.Mode)
+			return
+		}
+
+		s.Rewind()
+		if s.AtEnd() {
+			t.Errorf("Expected rewound %s sound to not be at its end\n", s.Info.Mode)
+			return
+		}
+	}
+
+	sink := wavy.NewStreamSink(1024, wavy.OverrunPolicy_Drop)
+	sinkSound := wavy.NewSoundStreamSink(sink)
+	defer sinkSound.Close()
+
+	if sinkSound.AtEnd() {
+		t.Errorf("Expected an open stream sink to not be at its end\n")
+		return
+	}
+
+	sink.Close()
+	sinkSound.PlaySync()
+	if !sinkSound.AtEnd() {
+		t.Errorf("Expected a closed and played stream sink to be at its end\n")
+		return
+	}
+}
+
+func ResetSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	s.PlaySync()
+	if s.RemainingTime() != 0 {
+		t.Errorf("Expected sound to be at its end after playing but remaining time is '%s'\n", s.RemainingTime())
+		return
+	}
+
+	s.Reset()
+	if s.IsPlaying() || s.IsLooping || s.Position() != 0 || s.RemainingTime() != s.TotalTime() {
+		t.Errorf("Expected Reset to return the sound to its start but position is '%s' and remaining time is '%s'\n", s.Position(), s.RemainingTime())
+		return
+	}
+
+	// The sound must play fully again
+	start := time.Now()
+	s.PlaySync()
+	if elapsed := time.Since(start); elapsed < s.TotalTime()-50*time.Millisecond {
+		t.Errorf("Expected replay after Reset to take about '%s' but took '%s'\n", s.TotalTime(), elapsed)
+		return
+	}
+
+	// Reset also stops a running loop
+	s.LoopAsync(-1)
+	time.Sleep(50 * time.Millisecond)
+	s.Reset()
+	if s.IsPlaying() || s.IsLooping || s.TotalLoops() != 0 {
+		t.Errorf("Expected Reset to stop the loop\n")
+		return
+	}
+}
+
+func MemCompressedSubtest(t *testing.T) {
+
+	const oggFilepath = "./test_audio_files/camera.ogg"
+
+	fileBytes, err := os.ReadFile(oggFilepath)
+	if err != nil {
+		t.Errorf("Failed to read file with path '%s'. Err: %s\n", oggFilepath, err)
+		return
+	}
+
+	s, err := wavy.NewSoundMemCompressed(fileBytes, wavy.SoundType_OGG)
+	if err != nil {
+		t.Errorf("Failed to load compressed memory sound. Err: %s\n", err)
+		return
+	}
+	defer s.Close()
+
+	memSound, err := wavy.NewSoundMem(oggFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", oggFilepath, err)
+		return
+	}
+	defer memSound.Close()
+
+	if s.TotalTime() != memSound.TotalTime() {
+		t.Errorf("Expected compressed sound to be '%s' long but got '%s'\n", memSound.TotalTime(), s.TotalTime())
+		return
+	}
+
+	s.SeekToPercent(0.5)
+	s.PlaySync()
+
+	if _, err := wavy.NewSoundMemCompressed(fileBytes, wavy.SoundType_Unknown); err == nil {
+		t.Errorf("Expected loading a compressed sound of unknown type to fail\n")
+		return
+	}
+}
+
+func ForkAtCurrentSubtest(t *testing.T) {
+
+	const wavFilepath = "./test_audio_files/camera.wav"
+
+	s, err := wavy.NewSoundMem(wavFilepath)
+	if err != nil {
+		t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", wavFilepath, err)
+		return
+	}
+	defer s.Close()
+
+	s.PlayAsync()
+	time.Sleep(100 * time.Millisecond)
+	s.Pause()
+
+	fork := wavy.ForkAtCurrent(s)
+	defer fork.Close()
+
+	if fork.RemainingTime() != s.RemainingTime() {
+		t.Errorf("Expected fork to have the same remaining time as its source ('%s') but got '%s'\n", s.RemainingTime(), fork.RemainingTime())
+		return
+	}
+
+	if fork.RemainingTime() == s.TotalTime() {
+		t.Errorf("Expected fork to not start at the beginning\n")
+		return
+	}
+
+	// The fork plays independently of its source
+	fork.PlaySync()
+	if s.RemainingTime() == 0 || fork.RemainingTime() != 0 {
+		t.Errorf("Expected only the fork to reach the end, but source remaining time is '%s' and fork remaining time is '%s'\n", s.RemainingTime(), fork.RemainingTime())
+		return
+	}
+}
+
+func ManifestSubtest(t *testing.T) {
+
+	const manifestJson = `{
+		"sounds": {
+			"click": { "path": "./test_audio_files/camera.wav", "volume": 0.5, "gainDb": -6 },
+			"music": { "path": "./test_audio_files/camera.ogg", "streaming": true, "volume": 0.25 },
+			"missing": { "path": "./test_audio_files/missing.wav" },
+			"badStreaming": { "path": "./test_audio_files/camera.ogg", "streaming": true, "stereoWidth": 2 }
+		}
+	}`
+
+	sounds, err := wavy.LoadFromManifest(strings.NewReader(manifestJson))
+	for _, s := range sounds {
+		defer s.Close()
+	}
+
+	var multiErr wavy.MultiError
+	if !errors.As(err, &multiErr) || len(multiErr) != 2 {
+		t.Errorf("Expected a MultiError with 2 errors but got '%v'\n", err)
+		return
+	}
+
+	if len(sounds) != 2 || sounds["click"] == nil || sounds["music"] == nil {
+		t.Errorf("Expected only 'click' and 'music' to load but got %d sounds\n", len(sounds))
+		return
+	}
+
+	if sounds["click"].Info.Mode != wavy.SoundMode_Memory || sounds["click"].Volume() != 0.5 {
+		t.Errorf("Expected 'click' to be in-memory with volume 0.5 but got %s\n", sounds["click"])
+		return
+	}
+
+	if sounds["music"].Info.Mode != wavy.SoundMode_Streaming || sounds["music"].Volume() != 0.25 {
+		t.Errorf("Expected 'music' to be streaming with volume 0.25 but got %s\n", sounds["music"])
+		return
+	}
+
+	// Unknown fields are rejected
+	_, err = wavy.LoadFromManifest(strings.NewReader(`{"sounds": {"click": {"path": "./test_audio_files/camera.wav", "volme": 0.5}}}`))
+	if !errors.Is(err, wavy.ErrInvalidManifest) {
+		t.Errorf("Expected ErrInvalidManifest for an unknown field but got '%v'\n", err)
+		return
+	}
+}
+
+func ProbeSubtest(t *testing.T) {
+
+	for _, fpath := range []string{"./test_audio_files/camera.ogg", "./test_audio_files/camera.wav", "./test_audio_files/tada.mp3"} {
+
+		info, err := wavy.ProbeSound(fpath)
+		if err != nil {
+			t.Errorf("Failed to probe sound with path '%s'. Err: %s\n", fpath, err)
+			return
+		}
+
+		s, err := wavy.NewSoundMem(fpath)
+		if err != nil {
+			t.Errorf("Failed to load memory sound with path '%s'. Err: %s\n", fpath, err)
+			return
+		}
+		s.Close()
+
+		diff := info.Duration - s.TotalTime()
+		if diff < -time.Millisecond || diff > time.Millisecond {
+			t.Errorf("Expected probed duration of '%s' to be '%s' like the decoded sound but got '%s'\n", fpath, s.TotalTime(), info.Duration)
+		}
+
+		if info.SampleRate != s.Info.NativeSampleRate || info.Type != s.Info.Type {
+			t.Errorf("Expected probed format of '%s' to match the loaded sound but got %+v\n", fpath, info)
+		}
+	}
+}
+
+// makeTestWav returns a 16-bit PCM WAV file with a 440 Hz sine wave that is 'frames' samples long per channel
+func makeTestWav(sampleRate uint32, chanCount uint16, frames int) []byte {
+	d := time.Duration(frames) * time.Second / time.Duration(sampleRate)
+	return wavy.GenerateTestWavFmt(d, wavy.SampleRate(sampleRate), wavy.SoundChannelCount(chanCount))
+}
+
+func TestGenerateTestWav(t *testing.T) {
+
+	tests := []struct {
+		d    time.Duration
+		rate wavy.SampleRate
+		ch   wavy.SoundChannelCount
+	}{
+		{time.Second, wavy.SampleRate_44100, wavy.SoundChannelCount_2},
+		{100 * time.Millisecond, wavy.SampleRate_48000, wavy.SoundChannelCount_1},
+		{0, wavy.SampleRate_44100, wavy.SoundChannelCount_2},
+	}
+
+	for _, tt := range tests {
+
+		wav := wavy.GenerateTestWavFmt(tt.d, tt.rate, tt.ch)
+		expectedSize := wavy.ByteCountFromPlayTimeFmt(tt.d, tt.rate, tt.ch, wavy.SoundBitDepth_2)
+		if int64(len(wav)) != 44+expectedSize || string(wav[:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+			t.Errorf("Expected a %d byte WAV for %s at %d Hz with %d channels but got %d bytes\n", 44+expectedSize, tt.d, tt.rate, tt.ch, len(wav))
+			return
+		}
+
+		if binary.LittleEndian.Uint32(wav[24:]) != uint32(tt.rate) || binary.LittleEndian.Uint16(wav[22:]) != uint16(tt.ch) || binary.LittleEndian.Uint32(wav[40:]) != uint32(expectedSize) {
+			t.Errorf("Expected the WAV header to describe %d Hz with %d channels and %d bytes of data\n", tt.rate, tt.ch, expectedSize)
+			return
+		}
+	}
+
+	// The tone starts at zero then rises
+	wav := wavy.GenerateTestWavFmt(time.Second, wavy.SampleRate_44100, wavy.SoundChannelCount_1)
+	if int16(binary.LittleEndian.Uint16(wav[44:])) != 0 || int16(binary.LittleEndian.Uint16(wav[46:])) <= 0 {
+		t.Errorf("Expected the generated sound to be a sine starting at zero\n")
+		return
+	}
+}
+
+func TestLoadError(t *testing.T) {
+
+	const missingFPath = "./test_audio_files/does_not_exist.mp3"
+
+	_, err := wavy.NewSoundMem(missingFPath)
+
+	var loadErr *wavy.LoadError
+	if !errors.As(err, &loadErr) {
+		t.Errorf("Expected a LoadError when loading a missing file but got '%v'\n", err)
+		return
+	}
+
+	if loadErr.Path != missingFPath || loadErr.Type != wavy.SoundType_MP3 {
+		t.Errorf("Expected LoadError with path '%s' and type MP3 but got path '%s' and type %s\n", missingFPath, loadErr.Path, loadErr.Type)
+		return
+	}
+
+	if !os.IsNotExist(loadErr.Err) {
+		t.Errorf("Expected the underlying error to be a not-exist error but got '%s'\n", loadErr.Err)
+		return
+	}
+
+	_, err = wavy.NewSoundStreaming("./test_audio_files/camera.txt")
+	if !errors.As(err, &loadErr) || loadErr.Type != wavy.SoundType_Unknown {
+		t.Errorf("Expected a LoadError with an unknown sound type but got '%v'\n", err)
+		return
+	}
+}
+
+func TestMaxInMemoryBytes(t *testing.T) {
+
+	wavy.SetMaxInMemoryBytes(1024)
+	defer wavy.SetMaxInMemoryBytes(wavy.DefaultMaxInMemoryBytes)
+
+	_, err := wavy.NewSoundMem("./test_audio_files/tada.mp3")
+	if !errors.Is(err, wavy.ErrFileTooLarge) {
+		t.Errorf("Expected loading a file bigger than the in-memory limit to fail with ErrFileTooLarge but got '%v'\n", err)
+		return
+	}
+}
+
+func TestCanStream(t *testing.T) {
+
+	for _, fpath := range []string{"./test_audio_files/camera.mp3", "./test_audio_files/camera.wav", "./test_audio_files/camera.ogg"} {
+
+		canStream, err := wavy.CanStream(fpath)
+		if err != nil || !canStream {
+			t.Errorf("Expected '%s' to be streamable but got canStream=%v and err='%v'\n", fpath, canStream, err)
+			return
+		}
+	}
+
+	if _, err := wavy.CanStream("./test_audio_files/camera.opus"); err != wavy.ErrUnsupportedSoundType {
+		t.Errorf("Expected ErrUnsupportedSoundType when probing an OPUS file but got '%v'\n", err)
+		return
+	}
+
+	if _, err := wavy.CanStream("./test_audio_files/missing.wav"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist when probing a missing file but got '%v'\n", err)
+		return
+	}
+}
+
+func TestSupportedSoundTypes(t *testing.T) {
+
+	for _, st := range wavy.SupportedSoundTypes() {
+		if !wavy.IsSoundTypeSupported(st) {
+			t.Errorf("Expected type %s returned by SupportedSoundTypes to be supported\n", st)
+			return
+		}
+	}
+
+	if wavy.IsSoundTypeSupported(wavy.GetSoundFileType("song.opus")) || wavy.IsSoundTypeSupported(wavy.GetSoundFileType("song.m4a")) || wavy.IsSoundTypeSupported(wavy.SoundType_Unknown) {
+		t.Errorf("Expected OPUS, AAC and Unknown sound types to be unsupported\n")
+		return
+	}
+
+	if wavy.GetSoundFileType("song.m4a").String() != "AAC" || wavy.GetSoundFileType("song.aac").String() != "AAC" {
+		t.Errorf("Expected .m4a and .aac files to be recognized as AAC\n")
+		return
+	}
+
+	if st := wavy.GetSoundFileType("song.opus"); st == wavy.SoundType_Unknown || st.String() != "OPUS" {
+		t.Errorf("Expected .opus files to be recognized as OPUS but got '%s'\n", st)
+		return
+	}
+
+	exts := wavy.SupportedExtensions()
+	if strings.Join(exts, " ") != ".mp3 .wav .wave .ogg" {
+		t.Errorf("Expected supported extensions to be '.mp3 .wav .wave .ogg' but got '%s'\n", strings.Join(exts, " "))
+		return
+	}
+
+	for _, ext := range exts {
+		if !wavy.IsSoundTypeSupported(wavy.GetSoundFileType("sound" + ext)) {
+			t.Errorf("Expected extension '%s' returned by SupportedExtensions to be of a supported type\n", ext)
+			return
+		}
+	}
+
+	_, err := wavy.NewSoundMem("./test_audio_files/camera.opus")
+	if !errors.Is(err, wavy.ErrUnsupportedSoundType) {
+		t.Errorf("Expected loading an OPUS file to fail with ErrUnsupportedSoundType but got '%v'\n", err)
+		return
+	}
+}
+
+func TestSoundBufferLenRemaining(t *testing.T) {
+
+	sb := &wavy.SoundBuffer{Data: make([]byte, 10)}
+	sb.Seek(4, io.SeekStart)
+	if sb.Len() != 10 || sb.Remaining() != 6 {
+		t.Errorf("Expected len=10 and remaining=6 but got len=%d and remaining=%d\n", sb.Len(), sb.Remaining())
+		return
+	}
+
+	// Reading past the end should give io.EOF instead of panicking
+	sb.Seek(20, io.SeekStart)
+	if n, err := sb.Read(make([]byte, 4)); n != 0 || err != io.EOF || sb.Remaining() != 0 {
+		t.Errorf("Expected reading past the end to return io.EOF with zero remaining but got n=%d, err='%v' and remaining=%d\n", n, err, sb.Remaining())
+		return
+	}
+
+	// Reads are counted across seeks
+	sb.Seek(0, io.SeekStart)
+	sb.Read(make([]byte, 4))
+	sb.Seek(8, io.SeekStart)
+	sb.Read(make([]byte, 4))
+	if sb.BytesRead() != 6 {
+		t.Errorf("Expected 6 bytes read but got %d\n", sb.BytesRead())
+		return
+	}
+}
+
+func TestReadAllFromReaderUnexpectedEOF(t *testing.T) {
+
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	newTruncatedReader := func() io.Reader {
+		return io.MultiReader(bytes.NewReader(data), iotest.ErrReader(io.ErrUnexpectedEOF))
+	}
+
+	// By default it's an error like any other
+	readData, err := wavy.ReadAllFromReader(newTruncatedReader(), 0, 0)
+	var partialErr *wavy.PartialDecodeError
+	if err != io.ErrUnexpectedEOF || !bytes.Equal(readData, data) {
+		t.Errorf("Expected io.ErrUnexpectedEOF along with the read data but got err='%v' and %d bytes\n", err, len(readData))
+		return
+	}
+
+	wavy.SetUnexpectedEOFAsPartial(true)
+	defer wavy.SetUnexpectedEOFAsPartial(false)
+
+	readData, err = wavy.ReadAllFromReader(newTruncatedReader(), 0, 0)
+	if !errors.As(err, &partialErr) || !errors.Is(err, io.ErrUnexpectedEOF) || !bytes.Equal(readData, data) {
+		t.Errorf("Expected a *PartialDecodeError wrapping io.ErrUnexpectedEOF along with the read data but got err='%v' and %d bytes\n", err, len(readData))
+		return
+	}
+
+	// Without any data there is nothing usable, so it stays an error
+	_, err = wavy.ReadAllFromReader(iotest.ErrReader(io.ErrUnexpectedEOF), 0, 0)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected io.ErrUnexpectedEOF when nothing was read but got '%v'\n", err)
+		return
+	}
+}
+
+func TestSoundsEqual(t *testing.T) {
+
+	newMemSound := func(samples ...int16) *wavy.Sound {
+
+		data := make([]byte, len(samples)*2)
+		for i, x := range samples {
+			binary.LittleEndian.PutUint16(data[i*2:], uint16(x))
+		}
+
+		return &wavy.Sound{
+			Data: &wavy.SoundBuffer{Data: data},
+			Info: wavy.SoundInfo{Mode: wavy.SoundMode_Memory, Size: int64(len(data))},
+		}
+	}
+
+	a := newMemSound(100, -100, 32767, -32768)
+	if !wavy.SoundsEqual(a, newMemSound(100, -100, 32767, -32768)) {
+		t.Errorf("Expected sounds with the same data to be equal\n")
+		return
+	}
+
+	near := newMemSound(102, -98, 32765, -32768)
+	if wavy.SoundsEqual(a, near) || !wavy.SoundsApproxEqual(a, near, 2) || wavy.SoundsApproxEqual(a, near, 1) {
+		t.Errorf("Expected sounds that differ by 2 to only be approximately equal with a tolerance of at least 2\n")
+		return
+	}
+
+	// Differences as big as the full range must not overflow
+	if wavy.SoundsApproxEqual(newMemSound(32767), newMemSound(-32768), 32767) {
+		t.Errorf("Expected opposite extremes to not be approximately equal\n")
+		return
+	}
+
+	if wavy.SoundsApproxEqual(a, newMemSound(100, -100), 10) {
+		t.Errorf("Expected sounds of different lengths to not be approximately equal\n")
+		return
+	}
+}
+
+func TestByteCountFromPlayTime(t *testing.T) {
+
+	got := wavy.ByteCountFromPlayTime(400 * time.Millisecond)
+	expected := int64(70560)
+	if got != expected {
+		t.Errorf("Expected '%d' but got '%d'\n", expected, got)
+		return
+	}
+}
+
+func TestPlayTimeFromByteCount(t *testing.T) {
+
+	got := wavy.PlayTimeFromByteCount(70560)
+	expected := 400 * time.Millisecond
+	if got != expected {
+		t.Errorf("Expected '%d' but got '%d'\n", expected, got)
+		return
+	}
+}
+
+func TestPlayTimeByteCountFmt(t *testing.T) {
+
+	// 1 second of 48000 Hz mono 16-bit PCM
+	got := wavy.ByteCountFromPlayTimeFmt(time.Second, wavy.SampleRate_48000, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2)
+	if got != 96000 {
+		t.Errorf("Expected '%d' but got '%d'\n", 96000, got)
+		return
+	}
+
+	gotTime := wavy.PlayTimeFromByteCountFmt(96000, wavy.SampleRate_48000, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2)
+	if gotTime != time.Second {
+		t.Errorf("Expected '%s' but got '%s'\n", time.Second, gotTime)
+		return
+	}
+
+	if wavy.PlayTimeFromByteCountFmt(96000, 0, wavy.SoundChannelCount_1, wavy.SoundBitDepth_2) != 0 {
+		t.Errorf("Expected zero play time for an invalid format\n")
+		return
+	}
+}
+
+func TestF32ToUnsignedPCM16Dithering(t *testing.T) {
+
+	fs := make([]float32, 1000)
+	for i := 0; i < len(fs); i++ {
+		fs[i] = float32(i-len(fs)/2) * 0.0000173
+	}
+
+	truncated := wavy.F32ToUnsignedPCM16(fs, nil)
+
+	wavy.SetDithering(true)
+	dithered := wavy.F32ToUnsignedPCM16(fs, nil)
+	wavy.SetDithering(false)
+
+	diffCount := 0
+	for i := 0; i < len(fs); i++ {
+
+		ideal := float64(fs[i]) * math.MaxInt16
+		if fs[i] < 0 {
+			ideal = float64(fs[i]) * -math.MinInt16
+		}
+
+		got := int16(uint16(dithered[i*2]) | uint16(dithered[i*2+1])<<8)
+		if math.Abs(float64(got)-ideal) > 1 {
+			t.Errorf("Expected dithered sample %d to be within 1 LSB of '%f' but got '%d'\n", i, ideal, got)
+			return
+		}
+
+		if dithered[i*2] != truncated[i*2] || dithered[i*2+1] != truncated[i*2+1] {
+			diffCount++
+		}
+	}
+
+	if diffCount == 0 {
+		t.Errorf("Expected dithered output to differ from truncated output\n")
+		return
+	}
+}
+
+// mockOggDecoder returns at most 'maxFloatsPerRead' floats per read, all with the value 0.5.
+// Once there are no floats left it returns 'endErr', or io.EOF if that's nil.
+// If 'changeFormatAfterReads' is bigger than zero the channel count changes after that many reads
+type mockOggDecoder struct {
+	maxFloatsPerRead       int
+	floatsLeft             int
+	endErr                 error
+	changeFormatAfterReads int
+	reads                  int
+}
+
+func (d *mockOggDecoder) Read(p []float32) (int, error) {
+
+	d.reads++
+
+	if d.floatsLeft == 0 {
+
+		if d.endErr != nil {
+			return 0, d.endErr
+		}
+
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	if n > d.maxFloatsPerRead {
+		n = d.maxFloatsPerRead
+	}
+
+	if n > d.floatsLeft {
+		n = d.floatsLeft
+	}
+
+	for i := 0; i < n; i++ {
+		p[i] = 0.5
+	}
+
+	d.floatsLeft -= n
+	return n, nil
+}
+
+func (d *mockOggDecoder) Length() int64               { return 0 }
+func (d *mockOggDecoder) Position() int64             { return 0 }
+func (d *mockOggDecoder) SetPosition(pos int64) error { return nil }
+func (d *mockOggDecoder) SampleRate() int             { return 44100 }
+
+func (d *mockOggDecoder) Channels() int {
+
+	if d.changeFormatAfterReads > 0 && d.reads >= d.changeFormatAfterReads {
+		return 1
+	}
+
+	return 2
+}
+
+func TestOggStreamerFormatChange(t *testing.T) {
+
+	oggStreamer := wavy.NewOggStreamer(nil, &mockOggDecoder{maxFloatsPerRead: 4, floatsLeft: 100, changeFormatAfterReads: 2})
+
+	outBuf := make([]byte, 8)
+	if _, err := oggStreamer.Read(outBuf); err != nil {
+		t.Errorf("Expected first read to succeed but got '%s'\n", err)
+		return
+	}
+
+	n, err := oggStreamer.Read(outBuf)
+	if err != wavy.ErrStreamFormatChanged || n != 0 {
+		t.Errorf("Expected read after a format change to return 0 bytes and ErrStreamFormatChanged but got %d bytes and '%v'\n", n, err)
+		return
+	}
+
+	select {
+	case err := <-oggStreamer.Errors():
+		if err != wavy.ErrStreamFormatChanged {
+			t.Errorf("Expected ErrStreamFormatChanged on the errors channel but got '%s'\n", err)
+		}
+	default:
+		t.Errorf("Expected ErrStreamFormatChanged on the errors channel\n")
+	}
+}
+
+func TestOggStreamerShortRead(t *testing.T) {
+
+	oggStreamer := wavy.NewOggStreamer(nil, &mockOggDecoder{maxFloatsPerRead: 3, floatsLeft: 5})
+
+	outBuf := make([]byte, 16)
+	for i := 0; i < len(outBuf); i++ {
+		outBuf[i] = 0xFF
+	}
+
+	n, err := oggStreamer.Read(outBuf)
+	if err != nil {
+		t.Errorf("Expected no error but got '%s'\n", err)
+		return
+	}
+
+	if n != 6 {
+		t.Errorf("Expected '6' bytes read but got '%d'\n", n)
+		return
+	}
+
+	for i := n; i < len(outBuf); i++ {
+		if outBuf[i] != 0 {
+			t.Errorf("Expected unwritten byte %d to be zero but got '%d'\n", i, outBuf[i])
+			return
+		}
+	}
+
+	// Only 2 floats are left
+	n, _ = oggStreamer.Read(outBuf)
+	if n != 4 {
+		t.Errorf("Expected '4' bytes read but got '%d'\n", n)
+		return
+	}
+
+	n, err = oggStreamer.Read(outBuf)
+	if n != 0 || err != io.EOF {
+		t.Errorf("Expected '0' bytes read and io.EOF but got '%d' and '%v'\n", n, err)
+		return
+	}
+}
+
+// makeID3v2Frame returns an ID3v2.3 frame with the given id and data
+func makeID3v2Frame(id string, data []byte) []byte {
+
+	frame := make([]byte, 10, 10+len(data))
+	copy(frame, id)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(data)))
+
+	return append(frame, data...)
+}
+
+// makeID3v2Tag returns an ID3v2.3 tag containing the given frames
+func makeID3v2Tag(frames ...[]byte) []byte {
+
+	body := bytes.Join(frames, nil)
+	size := len(body)
+
+	tag := []byte{'I', 'D', '3', 3, 0, 0, byte(size>>21) & 0x7F, byte(size>>14) & 0x7F, byte(size>>7) & 0x7F, byte(size) & 0x7F}
+	return append(tag, body...)
+}
+
+func TestReadMp3Tags(t *testing.T) {
+
+	artwork := []byte{0xFF, 0xD8, 0xFF, 0xE0, 1, 2, 3}
+
+	// UTF-16 with a little endian BOM
+	artistData := []byte{1, 0xFF, 0xFE, 'w', 0, 'a', 0, 'v', 0, 'y', 0}
+	apicData := append([]byte("\x00image/jpeg\x00\x03cover\x00"), artwork...)
+
+	file := makeID3v2Tag(
+		makeID3v2Frame("TIT2", []byte("\x00My Title")),
+		makeID3v2Frame("TPE1", artistData),
+		makeID3v2Frame("APIC", apicData),
+	)
+
+	// Fake audio then an ID3v1 tag that should only fill the missing album
+	file = append(file, make([]byte, 64)...)
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "V1 Title")
+	copy(v1[63:], "V1 Album")
+	v1[127] = 17
+	file = append(file, v1...)
+
+	tags, err := wavy.ReadMp3Tags(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Errorf("Failed to read mp3 tags. Err: %s\n", err)
+		return
+	}
+
+	if tags.Title != "My Title" || tags.Artist != "wavy" || tags.Album != "V1 Album" || tags.Genre != "17" {
+		t.Errorf("Got unexpected tags: %+v\n", tags)
+		return
+	}
+
+	if tags.ArtworkMIME != "image/jpeg" || !bytes.Equal(tags.Artwork, artwork) {
+		t.Errorf("Expected artwork '%v' with mime 'image/jpeg' but got '%v' with mime '%s'\n", artwork, tags.Artwork, tags.ArtworkMIME)
+		return
+	}
+}
+
+func TestStreamSink(t *testing.T) {
+
+	seq := func(from, to byte) []byte {
+		b := []byte{}
+		for i := from; i <= to; i++ {
+			b = append(b, i)
+		}
+		return b
+	}
+
+	sink := wavy.NewStreamSink(16, wavy.OverrunPolicy_Drop)
+
+	n, err := sink.Write(seq(1, 12))
+	if n != 12 || err != nil {
+		t.Errorf("Expected '12' bytes written and no error but got '%d' and '%v'\n", n, err)
+		return
+	}
+
+	// Only 4 bytes fit
+	n, err = sink.Write(seq(13, 20))
+	if n != 4 || err != wavy.ErrStreamSinkOverrun {
+		t.Errorf("Expected '4' bytes written and ErrStreamSinkOverrun but got '%d' and '%v'\n", n, err)
+		return
+	}
+
+	outBuf := make([]byte, 8)
+	sink.Read(outBuf)
+	if !bytes.Equal(outBuf, seq(1, 8)) {
+		t.Errorf("Expected '%v' but got '%v'\n", seq(1, 8), outBuf)
+		return
+	}
+
+	// Wraps around the end of the ring buffer
+	sink.Write(seq(21, 24))
+
+	// Underrun should be filled with silence
+	outBuf = make([]byte, 16)
+	n, err = sink.Read(outBuf)
+	expected := append(append(seq(9, 16), seq(21, 24)...), 0, 0, 0, 0)
+	if n != 16 || err != nil || !bytes.Equal(outBuf, expected) {
+		t.Errorf("Expected '16' bytes read with value '%v' but got '%d' bytes with value '%v' and err '%v'\n", expected, n, outBuf, err)
+		return
+	}
+
+	pos, _ := sink.Seek(0, io.SeekCurrent)
+	if pos != 24 {
+		t.Errorf("Expected position '24' but got '%d'\n", pos)
+		return
+	}
+
+	sink.Close()
+	if _, err := sink.Write([]byte{1}); err != wavy.ErrStreamSinkClosed {
+		t.Errorf("Expected ErrStreamSinkClosed but got '%v'\n", err)
+		return
+	}
+
+	if _, err := sink.Read(outBuf); err != io.EOF {
+		t.Errorf("Expected io.EOF but got '%v'\n", err)
+		return
+	}
+}
+
+func TestOggStreamerReadErrors(t *testing.T) {
+
+	readErr := errors.New("disk unplugged")
+	oggStreamer := wavy.NewOggStreamer(nil, &mockOggDecoder{maxFloatsPerRead: 4, floatsLeft: 4, endErr: readErr})
+
+	outBuf := make([]byte, 8)
+	oggStreamer.Read(outBuf)
+	oggStreamer.Read(outBuf)
+
+	select {
+	case err := <-oggStreamer.Errors():
+		if err != readErr {
+			t.Errorf("Expected error '%s' but got '%s'\n", readErr, err)
+			return
+		}
+	default:
+		t.Errorf("Expected error '%s' to be reported but got nothing\n", readErr)
+		return
+	}
+}
+
+func TestF32ToPCM16HQ(t *testing.T) {
+
+	fs := []float32{0, 1, -1, 1.5, -1.5, 0.5, -0.5, 0.00002, -0.00002}
+	expected := []int16{0, math.MaxInt16, math.MinInt16, math.MaxInt16, math.MinInt16, 16384, -16384, 1, -1}
+
+	out := wavy.F32ToPCM16HQ(fs, nil)
+	for i := 0; i < len(fs); i++ {
+
+		got := int16(uint16(out[i*2]) | uint16(out[i*2+1])<<8)
+		if got != expected[i] {
+			t.Errorf("Expected '%f' to be converted to '%d' but got '%d'\n", fs[i], expected[i], got)
+			return
+		}
+	}
+}
+
+func makeBenchFloats() []float32 {
+
+	fs := make([]float32, 44100*2)
+	for i := 0; i < len(fs); i++ {
+		fs[i] = float32(math.Sin(float64(i) * 0.01))
+	}
+
+	return fs
+}
+
+func BenchmarkF32ToUnsignedPCM16(b *testing.B) {
+
+	fs := makeBenchFloats()
+	outBuf := make([]byte, len(fs)*2)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wavy.F32ToUnsignedPCM16(fs, outBuf)
+	}
+}
+
+func BenchmarkF32ToPCM16HQ(b *testing.B) {
+
+	fs := makeBenchFloats()
+	outBuf := make([]byte, len(fs)*2)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wavy.F32ToPCM16HQ(fs, outBuf)
+	}
+}
+
+// benchmarkBatchLoad simulates loading many small sounds, where the temporary read buffer is a big part of the allocations
+func benchmarkBatchLoad(b *testing.B, pooling bool) {
+
+	wavy.SetReadBufferPooling(pooling)
+	defer wavy.SetReadBufferPooling(false)
+
+	data := make([]byte, 64*1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 100; j++ {
+			wavy.ReadAllFromReader(bytes.NewReader(data), 256*1024, uint64(len(data)))
+		}
+	}
+}
+
+func BenchmarkBatchLoad(b *testing.B) {
+	benchmarkBatchLoad(b, false)
+}
+
+func BenchmarkBatchLoadPooled(b *testing.B) {
+	benchmarkBatchLoad(b, true)
+}
+
+// initForBench initializes wavy if it isn't already, since benchmarks can run without TestWavy
+func initForBench(b *testing.B) {
+
+	if wavy.Ctx != nil {
+		return
+	}
+
+	if err := wavy.Init(wavy.SampleRate_44100, wavy.SoundChannelCount_2, wavy.SoundBitDepth_2); err != nil {
+		b.Fatalf("Failed to init wavy. Err: %s\n", err)
+	}
+}
+
+// BenchmarkCopyInMemSoundVoice and BenchmarkResetSoundVoice compare the cost of starting a rapid-fire sound effect
+// by copying the sound (which creates a new player every time) against resetting a finished copy and reusing its player
+func BenchmarkCopyInMemSoundVoice(b *testing.B) {
+
+	initForBench(b)
+	s, err := wavy.NewSoundMem("./test_audio_files/camera.wav")
+	if err != nil {
+		b.Fatalf("Failed to load sound. Err: %s\n", err)
+	}
+	defer s.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		voice := wavy.CopyInMemSound(s)
+		voice.Close()
+	}
+}
+
+func BenchmarkResetSoundVoice(b *testing.B) {
+
+	initForBench(b)
+	s, err := wavy.NewSoundMem("./test_audio_files/camera.wav")
+	if err != nil {
+		b.Fatalf("Failed to load sound. Err: %s\n", err)
+	}
+	defer s.Close()
+
+	voice := wavy.CopyInMemSound(s)
+	defer voice.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		voice.Reset()
+	}
+}
@@ -0,0 +1,53 @@
+package wavy
+
+// snapToZeroCrossing returns the start of the frame nearest to bytePos, within windowBytes before or after it, where the waveform crosses zero.
+// A crossing is a frame whose sample is zero or has a different sign than the previous frame, using the sum of all channels.
+// bytePos is returned as is if there is no crossing in the window, or if it's the start or end of pcm since those are natural cut points.
+// bytePos must be frame aligned
+func snapToZeroCrossing(pcm []byte, bytePos, windowBytes int64) int64 {
+
+	frameSize := BytesPerSample
+	if frameSize == 0 || bytePos <= 0 || bytePos >= int64(len(pcm)) {
+		return bytePos
+	}
+
+	frameCount := int64(len(pcm)) / frameSize
+
+	frame := bytePos / frameSize
+	window := windowBytes / frameSize
+	for d := int64(0); d <= window; d++ {
+
+		if isZeroCrossing(pcm, frame-d, frameCount) {
+			return (frame - d) * frameSize
+		}
+
+		if d > 0 && isZeroCrossing(pcm, frame+d, frameCount) {
+			return (frame + d) * frameSize
+		}
+	}
+
+	return bytePos
+}
+
+func isZeroCrossing(pcm []byte, frame, frameCount int64) bool {
+
+	if frame < 1 || frame >= frameCount {
+		return false
+	}
+
+	curr := frameSum(pcm, frame)
+	prev := frameSum(pcm, frame-1)
+	return curr == 0 || (curr > 0) != (prev > 0)
+}
+
+// frameSum returns the sum of the samples of all channels of a PCM16 frame
+func frameSum(pcm []byte, frame int64) int {
+
+	sum := 0
+	start := int(frame * BytesPerSample)
+	for i := start; i+1 < start+int(BytesPerSample); i += 2 {
+		sum += int(getPCM16Sample(pcm, i))
+	}
+
+	return sum
+}
@@ -0,0 +1,41 @@
+package wavy
+
+import (
+	"testing"
+)
+
+func TestSnapToZeroCrossing(t *testing.T) {
+
+	oldBytesPerSample := BytesPerSample
+	BytesPerSample = 4
+	defer func() { BytesPerSample = oldBytesPerSample }()
+
+	// Stereo frames where the sum of channels crosses zero at frame 6 (positive to negative)
+	sums := []int16{100, 200, 300, 200, 100, 50, -50, -100, -200, -300}
+	pcm := make([]byte, len(sums)*4)
+	for i, x := range sums {
+		putPCM16Sample(pcm, i*4, x/2)
+		putPCM16Sample(pcm, i*4+2, x/2)
+	}
+
+	tests := []struct {
+		name     string
+		frame    int64
+		window   int64
+		expected int64
+	}{
+		{name: "Before", frame: 3, window: 4, expected: 6},
+		{name: "After", frame: 9, window: 3, expected: 6},
+		{name: "OnCrossing", frame: 6, window: 2, expected: 6},
+		{name: "OutsideWindow", frame: 2, window: 2, expected: 2},
+		{name: "Start", frame: 0, window: 10, expected: 0},
+	}
+
+	for _, test := range tests {
+
+		got := snapToZeroCrossing(pcm, test.frame*4, test.window*4) / 4
+		if got != test.expected {
+			t.Errorf("%s: expected snapping frame %d to give frame %d but got %d\n", test.name, test.frame, test.expected, got)
+		}
+	}
+}